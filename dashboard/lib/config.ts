@@ -7,3 +7,32 @@ export const config = {
 export const getApiUrl = (path: string) => {
   return `${config.apiGatewayUrl}${path}`;
 };
+
+// The organization API key is entered at runtime and kept for the browser
+// session only; it is never part of the build.
+const apiKeyStorageKey = "api-observatory:api-key";
+
+export const getApiKey = () => {
+  if (typeof window === "undefined") return null;
+  return window.sessionStorage.getItem(apiKeyStorageKey);
+};
+
+export const setApiKey = (key: string) => {
+  window.sessionStorage.setItem(apiKeyStorageKey, key);
+};
+
+export const clearApiKey = () => {
+  window.sessionStorage.removeItem(apiKeyStorageKey);
+};
+
+// The gateway scopes every response to the organization owning the API key.
+export const apiFetch = (path: string, init: RequestInit = {}) => {
+  return fetch(getApiUrl(path), {
+    ...init,
+    headers: { ...init.headers, "X-API-Key": getApiKey() ?? "" },
+  });
+};
+
+export const getWsUrl = () => {
+  return `${config.wsUrl}?api_key=${encodeURIComponent(getApiKey() ?? "")}`;
+};
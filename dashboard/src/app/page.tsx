@@ -9,21 +9,56 @@ import { Alert } from "@/components/ui/alert";
 import RealTimeMetrics from "../components/RealTimeMetrics";
 import CostDashboard from "../components/CostDashboard";
 import OptimizationPanel from "../components/OptimizationPanel";
-import { config, getApiUrl } from "../../lib/config";
+import ApiKeyForm from "../components/ApiKeyForm";
+import {
+  apiFetch,
+  clearApiKey,
+  getApiKey,
+  getWsUrl,
+  setApiKey,
+} from "../../lib/config";
 
 export default function Home() {
   const [dashboardData, setDashboardData] = useState<any>(null);
   const [ws, setWs] = useState<WebSocket | null>(null);
   const [isConnected, setIsConnected] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [apiKey, setApiKeyState] = useState<string | null>(null);
+  const [keyChecked, setKeyChecked] = useState(false);
+  const [keyError, setKeyError] = useState<string | null>(null);
 
   useEffect(() => {
+    setApiKeyState(getApiKey());
+    setKeyChecked(true);
+  }, []);
+
+  const signIn = (key: string) => {
+    setApiKey(key);
+    setKeyError(null);
+    setDashboardData(null);
+    setApiKeyState(key);
+  };
+
+  const signOut = (message: string | null = null) => {
+    clearApiKey();
+    setKeyError(message);
+    setDashboardData(null);
+    setApiKeyState(null);
+  };
+
+  useEffect(() => {
+    if (!apiKey) return;
+
     let ws: WebSocket;
     let pollInterval: NodeJS.Timeout = null;
 
     const fetchDashboardData = async () => {
       try {
-        const response = await fetch(getApiUrl("/api/dashboard/summary"));
+        const response = await apiFetch("/api/dashboard/summary");
+        if (response.status === 401) {
+          signOut("That API key was not accepted.");
+          return;
+        }
         if (!response.ok) {
           throw new Error(`HTTP error! status: ${response.status}`);
         }
@@ -45,7 +80,7 @@ export default function Home() {
     pollInterval = setInterval(fetchDashboardData, 3000);
 
     try {
-      ws = new WebSocket(config.wsUrl);
+      ws = new WebSocket(getWsUrl());
 
       ws.onopen = () => setIsConnected(true);
       ws.onclose = () => setIsConnected(false);
@@ -65,7 +100,15 @@ export default function Home() {
         clearInterval(pollInterval);
       };
     } catch {}
-  }, []);
+  }, [apiKey]);
+
+  if (!keyChecked) {
+    return null;
+  }
+
+  if (!apiKey) {
+    return <ApiKeyForm error={keyError} onSubmit={signIn} />;
+  }
 
   if (!dashboardData) {
     return (
@@ -89,9 +132,17 @@ export default function Home() {
               {isConnected ? "LIVE" : "OFFLINE"}
             </Badge>
           </div>
-          <button className="rounded-md px-3 py-2 bg-primary text-primary-foreground shadow hover:bg-primary/90 transition">
-            New Monitor
-          </button>
+          <div className="flex items-center gap-2">
+            <button
+              onClick={() => signOut()}
+              className="rounded-md px-3 py-2 border shadow hover:bg-accent transition"
+            >
+              Change API key
+            </button>
+            <button className="rounded-md px-3 py-2 bg-primary text-primary-foreground shadow hover:bg-primary/90 transition">
+              New Monitor
+            </button>
+          </div>
         </div>
 
         {error && <Alert variant="destructive">{error}</Alert>}
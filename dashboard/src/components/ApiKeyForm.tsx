@@ -0,0 +1,50 @@
+"use client";
+
+import { useState } from "react";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import { Alert } from "@/components/ui/alert";
+import { Button } from "@/components/ui/button";
+
+export default function ApiKeyForm({
+  error,
+  onSubmit,
+}: {
+  error: string | null;
+  onSubmit: (key: string) => void;
+}) {
+  const [key, setKey] = useState("");
+
+  return (
+    <div className="flex items-center justify-center h-screen bg-background">
+      <Card className="w-full max-w-md">
+        <CardHeader>
+          <CardTitle>API Observatory</CardTitle>
+        </CardHeader>
+        <CardContent>
+          <form
+            className="space-y-4"
+            onSubmit={(e) => {
+              e.preventDefault();
+              if (key.trim()) onSubmit(key.trim());
+            }}
+          >
+            <label className="block text-sm text-muted-foreground">
+              Organization API key
+              <input
+                type="password"
+                autoComplete="off"
+                value={key}
+                onChange={(e) => setKey(e.target.value)}
+                className="mt-1 w-full rounded-md border bg-background px-3 py-2 text-foreground"
+              />
+            </label>
+            {error && <Alert variant="destructive">{error}</Alert>}
+            <Button type="submit" className="w-full">
+              Open dashboard
+            </Button>
+          </form>
+        </CardContent>
+      </Card>
+    </div>
+  );
+}
@@ -14,18 +14,196 @@ CREATE TABLE organizations (
 CREATE TABLE api_providers (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
-    base_cost_per_request DECIMAL(10, 6) DEFAULT 0,
+    base_cost_per_request DECIMAL(10, 6) DEFAULT 0, -- current list price, mirrored into price_versions
     rate_limit_per_minute INTEGER DEFAULT 60,
+    bills_failed_requests BOOLEAN DEFAULT FALSE, -- provider charges for 4xx/5xx calls
     created_at TIMESTAMP DEFAULT NOW()
 );
 
 -- Insert sample providers
-INSERT INTO api_providers (name, base_cost_per_request, rate_limit_per_minute) VALUES
-('OpenAI', 0.002, 3500),
-('Stripe', 0.0001, 100),
-('SendGrid', 0.0005, 600),
-('Twilio', 0.0075, 1000),
-('AWS S3', 0.0004, 3500);
+INSERT INTO api_providers (name, base_cost_per_request, rate_limit_per_minute, bills_failed_requests) VALUES
+('OpenAI', 0.002, 3500, FALSE),
+('Stripe', 0.0001, 100, FALSE),
+('SendGrid', 0.0005, 600, FALSE),
+('Twilio', 0.0075, 1000, TRUE),
+('AWS S3', 0.0004, 3500, TRUE);
+
+-- Effective-dated prices. A request is priced by the provider's latest
+-- version effective at the request's time, and records its id.
+CREATE TABLE price_versions (
+    id SERIAL PRIMARY KEY,
+    provider VARCHAR(100) NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL,
+    base_cost_per_request DECIMAL(10, 6) NOT NULL DEFAULT 0,
+    free_requests_per_period INTEGER NOT NULL DEFAULT 0, -- free-tier allowance each billing month
+    minimum_monthly_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (provider, effective_from)
+);
+
+INSERT INTO price_versions (provider, effective_from, base_cost_per_request, free_requests_per_period)
+SELECT name, '1970-01-01', base_cost_per_request,
+    CASE name WHEN 'SendGrid' THEN 3000 WHEN 'AWS S3' THEN 2000 ELSE 0 END
+FROM api_providers;
+
+-- Volume tiers of a price version. A tier's unit cost applies to requests
+-- past from_requests in the organization's billing month; versions without
+-- tiers are priced at base_cost_per_request.
+CREATE TABLE pricing_tiers (
+    price_version_id INTEGER NOT NULL REFERENCES price_versions (id) ON DELETE CASCADE,
+    from_requests BIGINT NOT NULL,
+    unit_cost DECIMAL(12, 8) NOT NULL,
+    PRIMARY KEY (price_version_id, from_requests)
+);
+
+INSERT INTO pricing_tiers (price_version_id, from_requests, unit_cost)
+SELECT v.id, t.from_requests, t.unit_cost
+FROM price_versions v
+JOIN (VALUES
+    ('Stripe', 0, 0.0001),
+    ('Stripe', 100000, 0.00006),
+    ('Stripe', 1000000, 0.00003),
+    ('SendGrid', 0, 0.0005),
+    ('SendGrid', 50000, 0.0003),
+    ('SendGrid', 300000, 0.0002)
+) AS t (provider, from_requests, unit_cost) ON t.provider = v.provider;
+
+-- Cost recomputation jobs, run by the cost-tracker. range_end NULL means up
+-- to the time the job runs.
+CREATE TABLE cost_backfills (
+    id SERIAL PRIMARY KEY,
+    provider VARCHAR(100) NOT NULL,
+    organization_id INTEGER, -- NULL for every organization
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    reason TEXT,
+    rows_updated BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    requested_at TIMESTAMPTZ DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    claimed_by VARCHAR(255), -- instance running the job
+    heartbeat_at TIMESTAMPTZ, -- last sign of life from that instance
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_cost_backfills_pending ON cost_backfills (requested_at) WHERE status = 'pending';
+
+-- Changing api_providers.base_cost_per_request starts a new price version
+-- (with the previous version's allowance, fee and tiers) from now on.
+CREATE FUNCTION record_price_change() RETURNS trigger AS $$
+DECLARE
+    prev price_versions%ROWTYPE;
+    version_id INTEGER;
+BEGIN
+    SELECT * INTO prev FROM price_versions
+    WHERE provider = NEW.name AND effective_from <= NOW()
+    ORDER BY effective_from DESC LIMIT 1;
+    IF FOUND AND prev.base_cost_per_request = NEW.base_cost_per_request THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO price_versions (provider, effective_from, base_cost_per_request, free_requests_per_period, minimum_monthly_fee, note)
+    VALUES (NEW.name, NOW(), NEW.base_cost_per_request,
+        COALESCE(prev.free_requests_per_period, 0), COALESCE(prev.minimum_monthly_fee, 0), 'api_providers update')
+    RETURNING id INTO version_id;
+
+    INSERT INTO pricing_tiers (price_version_id, from_requests, unit_cost)
+    SELECT version_id, from_requests, unit_cost FROM pricing_tiers WHERE price_version_id = prev.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER api_providers_price_change
+AFTER UPDATE OF base_cost_per_request ON api_providers
+FOR EACH ROW WHEN (OLD.base_cost_per_request IS DISTINCT FROM NEW.base_cost_per_request)
+EXECUTE FUNCTION record_price_change();
+
+-- A backdated price version is a correction: queue a backfill for the
+-- requests it now covers, and keep api_providers showing the current price.
+-- Insert a correction's tiers in the same transaction.
+CREATE FUNCTION queue_price_backfill() RETURNS trigger AS $$
+BEGIN
+    IF NEW.effective_from < NOW() THEN
+        INSERT INTO cost_backfills (provider, range_start, range_end, reason)
+        SELECT NEW.provider, NEW.effective_from, MIN(effective_from),
+            COALESCE(NEW.note, 'price version ' || NEW.id)
+        FROM price_versions
+        WHERE provider = NEW.provider AND effective_from > NEW.effective_from;
+    END IF;
+
+    UPDATE api_providers SET base_cost_per_request = NEW.base_cost_per_request
+    WHERE name = NEW.provider AND NOT EXISTS (
+        SELECT 1 FROM price_versions
+        WHERE provider = NEW.provider AND effective_from > NEW.effective_from AND effective_from <= NOW()
+    ) AND NEW.effective_from <= NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER price_versions_backfill
+AFTER INSERT ON price_versions
+FOR EACH ROW EXECUTE FUNCTION queue_price_backfill();
+
+-- Committed-use contracts: a discount on unit prices in exchange for a
+-- monthly spend that is billed in full even when unused
+CREATE TABLE pricing_commitments (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    provider VARCHAR(100) NOT NULL,
+    committed_monthly_spend DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    discount_percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    starts_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    ends_on DATE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_pricing_commitments_org ON pricing_commitments (organization_id, provider);
+
+-- Period-level charges that aren't attached to a request (minimum fees and
+-- commitment shortfalls), maintained by the cost-tracker's true-up job
+CREATE TABLE cost_adjustments (
+    organization_id INTEGER NOT NULL,
+    provider VARCHAR(100) NOT NULL,
+    period_start DATE NOT NULL,
+    kind VARCHAR(30) NOT NULL, -- minimum_fee, commitment_shortfall
+    amount DECIMAL(12, 6) NOT NULL,
+    final BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (organization_id, provider, period_start, kind)
+);
+
+-- Pricing catalog for what-if comparisons. Cost of a period is
+-- monthly_fee + requests beyond included_requests * cost_per_request +
+-- KB transferred * cost_per_kb. Alternative-provider prices are illustrative
+-- list prices; edit them to match your quotes and contracts.
+CREATE TABLE pricing_plans (
+    id SERIAL PRIMARY KEY,
+    provider VARCHAR(100) NOT NULL,
+    plan_name VARCHAR(100) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    cost_per_request DECIMAL(10, 6) NOT NULL DEFAULT 0,
+    cost_per_kb DECIMAL(12, 8) NOT NULL DEFAULT 0.00001,
+    monthly_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    included_requests INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (provider, plan_name)
+);
+
+INSERT INTO pricing_plans (provider, plan_name, category, cost_per_request, monthly_fee, included_requests) VALUES
+('OpenAI', 'Standard', 'llm', 0.002, 0, 0),
+('OpenAI', 'Batch', 'llm', 0.001, 0, 0),
+('Anthropic', 'Standard', 'llm', 0.0024, 0, 0),
+('Stripe', 'Standard', 'payments', 0.0001, 0, 0),
+('Adyen', 'Standard', 'payments', 0.00012, 0, 0),
+('SendGrid', 'Standard', 'email', 0.0005, 0, 0),
+('SendGrid', 'Pro', 'email', 0.0003, 89.95, 100000),
+('Amazon SES', 'Standard', 'email', 0.0001, 0, 0),
+('Mailgun', 'Foundation', 'email', 0.0008, 35, 50000),
+('Twilio', 'Standard', 'sms', 0.0075, 0, 0),
+('Vonage', 'Standard', 'sms', 0.0068, 0, 0),
+('AWS S3', 'Standard', 'storage', 0.0004, 0, 0),
+('Cloudflare R2', 'Standard', 'storage', 0.00036, 0, 0);
 
 -- API requests table (hypertable for time-series data)
 CREATE TABLE api_requests (
@@ -41,7 +219,9 @@ CREATE TABLE api_requests (
     response_size_bytes INTEGER,
     cost DECIMAL(10, 6),
     error_message TEXT,
+    error_class VARCHAR(30), -- auth, rate_limit, validation, not_found, timeout, provider_outage, client_bug
     metadata JSONB,
+    price_version_id INTEGER, -- price_versions row the cost was computed from
     PRIMARY KEY (time, request_id)
 );
 
@@ -53,6 +233,7 @@ CREATE INDEX idx_api_requests_org_time ON api_requests (organization_id, time DE
 CREATE INDEX idx_api_requests_provider ON api_requests (provider, time DESC);
 CREATE INDEX idx_api_requests_endpoint ON api_requests (endpoint, time DESC);
 CREATE INDEX idx_api_requests_status ON api_requests (status_code, time DESC);
+CREATE INDEX idx_api_requests_error_class ON api_requests (error_class, time DESC) WHERE error_class IS NOT NULL;
 
 -- Duplicate requests detection table
 CREATE TABLE duplicate_requests (
@@ -77,9 +258,10 @@ CREATE TABLE cache_recommendations (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
--- Cost aggregations (continuous aggregate)
+-- Cost aggregations (continuous aggregate). materialized_only = false lets
+-- queries see the hours the refresh policy hasn't materialized yet.
 CREATE MATERIALIZED VIEW api_costs_hourly
-WITH (timescaledb.continuous) AS
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
 SELECT
     time_bucket('1 hour', time) AS bucket,
     organization_id,
@@ -98,6 +280,27 @@ SELECT add_continuous_aggregate_policy('api_costs_hourly',
     end_offset => INTERVAL '1 hour',
     schedule_interval => INTERVAL '1 hour');
 
+-- Per-endpoint hourly costs, for long-range breakdowns by endpoint
+CREATE MATERIALIZED VIEW api_endpoint_costs_hourly
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    time_bucket('1 hour', time) AS bucket,
+    organization_id,
+    provider,
+    endpoint,
+    COUNT(*) as request_count,
+    SUM(cost) as total_cost,
+    AVG(latency_ms) as avg_latency,
+    COUNT(CASE WHEN status_code >= 400 THEN 1 END) as error_count
+FROM api_requests
+GROUP BY bucket, organization_id, provider, endpoint
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('api_endpoint_costs_hourly',
+    start_offset => INTERVAL '3 hours',
+    end_offset => INTERVAL '1 hour',
+    schedule_interval => INTERVAL '1 hour');
+
 -- Anomaly detection results
 CREATE TABLE anomalies (
     id SERIAL PRIMARY KEY,
@@ -110,6 +313,98 @@ CREATE TABLE anomalies (
     metadata JSONB
 );
 
+-- Per-organization anomaly detection settings. cost_spike_sensitivity is the
+-- robust z-score (median/MAD by UTC hour-of-week slot) above which an hour is
+-- flagged; higher values mean fewer alerts. cost_spike_scale_floor is the
+-- smallest deviation scale as a fraction of the slot median, so small
+-- wobbles on a steady series don't score as spikes.
+CREATE TABLE anomaly_settings (
+    organization_id INTEGER PRIMARY KEY,
+    cost_spike_sensitivity DECIMAL(5, 2) NOT NULL DEFAULT 3.5,
+    cost_spike_scale_floor DECIMAL(4, 3) NOT NULL DEFAULT 0.1,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Spend budgets per organization, optionally narrowed to a provider or a
+-- metadata tag. Custom periods use period_start/period_end; monthly and
+-- weekly periods are calendar months and ISO weeks in UTC.
+CREATE TABLE budgets (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    period VARCHAR(10) NOT NULL DEFAULT 'monthly' CHECK (period IN ('monthly', 'weekly', 'custom')),
+    period_start TIMESTAMPTZ,
+    period_end TIMESTAMPTZ,
+    provider VARCHAR(100),
+    tag_key VARCHAR(100),
+    tag_value VARCHAR(255),
+    thresholds INTEGER[] NOT NULL DEFAULT '{50,80,100}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_budgets_scope ON budgets (
+    organization_id, period,
+    COALESCE(provider, ''), COALESCE(tag_key, ''), COALESCE(tag_value, ''),
+    COALESCE(period_start, 'epoch'::timestamptz)
+);
+
+-- One row per threshold crossed per budget period, so each alert fires once
+CREATE TABLE budget_alerts (
+    id SERIAL PRIMARY KEY,
+    budget_id INTEGER NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    threshold INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'actual',
+    spend DECIMAL(12, 4) NOT NULL,
+    fired_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (budget_id, period_start, threshold, kind)
+);
+
+-- How shared or untagged spend is split for chargeback. A rule moves the
+-- cost of requests whose tag_key is source_value (NULL for untagged) onto
+-- other values: proportionally to their direct cost, or by the fixed weights
+-- in targets, e.g. {"search": 0.6, "ads": 0.4}. Proportional rules may list
+-- targets to restrict which values share the cost.
+CREATE TABLE allocation_rules (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL,
+    tag_key VARCHAR(100) NOT NULL,
+    source_value VARCHAR(255),
+    method VARCHAR(20) NOT NULL DEFAULT 'proportional' CHECK (method IN ('proportional', 'fixed')),
+    targets JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_allocation_rules_source ON allocation_rules (
+    organization_id, tag_key, COALESCE(source_value, '')
+);
+
+-- Hourly cost per value of each chargeback allocation key, extended by the
+-- cost-tracker every run so reports don't rescan the month. tag_value is ''
+-- for requests without the tag.
+CREATE TABLE tag_costs_hourly (
+    organization_id INTEGER NOT NULL,
+    tag_key VARCHAR(100) NOT NULL,
+    tag_value TEXT NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    request_count BIGINT NOT NULL,
+    total_cost DECIMAL(14, 6) NOT NULL,
+    PRIMARY KEY (organization_id, tag_key, bucket, tag_value)
+);
+
+-- Chargeback reports of months whose costs can no longer change, computed
+-- once. Repricing a month deletes its rows so they are rebuilt.
+CREATE TABLE chargeback_reports (
+    organization_id INTEGER NOT NULL,
+    tag_key VARCHAR(100) NOT NULL,
+    month DATE NOT NULL,
+    report JSONB NOT NULL,
+    computed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (organization_id, tag_key, month)
+);
+
 -- Create sample organization
 INSERT INTO organizations (name, api_key) VALUES
 ('Demo Organization', 'demo_api_key_12345');
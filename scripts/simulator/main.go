@@ -180,6 +180,8 @@ func NewSimulator(config Config) *Simulator {
 	}
 }
 
+var simulatedTeams = []string{"search", "checkout", "growth", "platform"}
+
 func (s *Simulator) Run() {
 	ctx := make(chan struct{})
 	var wg sync.WaitGroup
@@ -260,6 +262,12 @@ func (s *Simulator) sendRequest() {
 		},
 	}
 
+	// Most traffic is tagged with the owning team; the rest is left untagged
+	// so chargeback has shared spend to allocate.
+	if rand.Float64() < 0.85 {
+		req.Metadata["team"] = simulatedTeams[rand.Intn(len(simulatedTeams))]
+	}
+
 	if err := s.ingestRequest(req); err != nil {
 		s.stats.mu.Lock()
 		s.stats.failedRequests++
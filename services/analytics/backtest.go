@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Incident is one labeled entry of a backtest incidents file. An empty Type
+// or zero OrganizationID matches any alert.
+type Incident struct {
+	OrganizationID int       `json:"organization_id"`
+	Type           string    `json:"type"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Label          string    `json:"label"`
+}
+
+type BacktestReport struct {
+	Detector  string            `json:"detector"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Params    map[string]string `json:"params"`
+	Alerts    []Anomaly         `json:"alerts"`
+	Incidents int               `json:"incidents,omitempty"`
+	Detected  int               `json:"detected,omitempty"`
+	Matched   int               `json:"matched_alerts,omitempty"`
+	Precision *float64          `json:"precision,omitempty"`
+	Recall    *float64          `json:"recall,omitempty"`
+}
+
+// runBacktest implements `analytics-service backtest`. Any detector in r can
+// be replayed, as can the streaming engine under the name "stream". It
+// returns the process exit code.
+func (s *AnalyticsServer) runBacktest(r *Registry, args []string) int {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	detector := fs.String("detector", "cost_spikes", "Detector to replay, or stream for the streaming engine")
+	fromFlag := fs.String("from", "", "Start of the range (RFC3339)")
+	toFlag := fs.String("to", "", "End of the range (RFC3339, default now)")
+	incidentsFile := fs.String("incidents", "", "JSON file of labeled incidents to score against")
+	tolerance := fs.Duration("tolerance", 30*time.Minute, "How far outside an incident an alert may fire and still match")
+	step := fs.Duration("step", 0, "Time between replayed runs (default the detector's interval)")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	params := Params{}
+	fs.Var(params, "param", "Detector parameter as key=value (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	d, ok := r.Get(*detector)
+	if !ok && *detector != "stream" {
+		names := []string{}
+		for _, d := range r.Detectors() {
+			names = append(names, d.Name())
+		}
+		names = append(names, "stream")
+		fmt.Fprintf(os.Stderr, "unknown detector %q (available: %s)\n", *detector, strings.Join(names, ", "))
+		return 2
+	}
+
+	to := time.Now()
+	if *toFlag != "" {
+		t, err := time.Parse(time.RFC3339, *toFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -to: %v\n", err)
+			return 2
+		}
+		to = t
+	}
+	from := to.Add(-7 * 24 * time.Hour)
+	if *fromFlag != "" {
+		t, err := time.Parse(time.RFC3339, *fromFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -from: %v\n", err)
+			return 2
+		}
+		from = t
+	}
+
+	var alerts []Anomaly
+	var err error
+	if d != nil {
+		alerts, err = replayDetector(context.Background(), d, from, to, *step, params)
+	} else {
+		alerts, err = s.backtestStream(context.Background(), from, to, params)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backtest failed: %v\n", err)
+		return 1
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].DetectedAt.Before(alerts[j].DetectedAt) })
+
+	report := BacktestReport{
+		Detector: *detector,
+		From:     from,
+		To:       to,
+		Params:   params,
+		Alerts:   alerts,
+	}
+
+	if *incidentsFile != "" {
+		incidents, err := loadIncidents(*incidentsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read incidents: %v\n", err)
+			return 1
+		}
+		scoreBacktest(&report, incidents, *tolerance)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return 0
+	}
+
+	printBacktest(report)
+	return 0
+}
+
+func loadIncidents(path string) ([]Incident, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var incidents []Incident
+	if err := json.Unmarshal(data, &incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+func (inc Incident) matches(a Anomaly, tolerance time.Duration) bool {
+	if inc.OrganizationID != 0 && inc.OrganizationID != a.OrganizationID {
+		return false
+	}
+	if inc.Type != "" && inc.Type != a.Type {
+		return false
+	}
+	return !a.DetectedAt.Before(inc.Start.Add(-tolerance)) && !a.DetectedAt.After(inc.End.Add(tolerance))
+}
+
+// scoreBacktest computes precision (alerts that fall on an incident) and
+// recall (incidents with at least one alert).
+func scoreBacktest(report *BacktestReport, incidents []Incident, tolerance time.Duration) {
+	detected := make([]bool, len(incidents))
+	for _, a := range report.Alerts {
+		matched := false
+		for i, inc := range incidents {
+			if inc.matches(a, tolerance) {
+				detected[i] = true
+				matched = true
+			}
+		}
+		if matched {
+			report.Matched++
+		}
+	}
+	for _, d := range detected {
+		if d {
+			report.Detected++
+		}
+	}
+
+	report.Incidents = len(incidents)
+	if len(report.Alerts) > 0 {
+		precision := float64(report.Matched) / float64(len(report.Alerts))
+		report.Precision = &precision
+	}
+	if len(incidents) > 0 {
+		recall := float64(report.Detected) / float64(len(incidents))
+		report.Recall = &recall
+	}
+}
+
+func printBacktest(report BacktestReport) {
+	fmt.Printf("Backtest of %s from %s to %s\n", report.Detector, report.From.Format(time.RFC3339), report.To.Format(time.RFC3339))
+	if len(report.Params) > 0 {
+		fmt.Printf("Parameters: %s\n", Params(report.Params))
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tORG\tTYPE\tSEVERITY\tDESCRIPTION")
+	for _, a := range report.Alerts {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", a.DetectedAt.Format(time.RFC3339), a.OrganizationID, a.Type, a.Severity, a.Description)
+	}
+	tw.Flush()
+
+	fmt.Printf("\n%d alerts would have fired.\n", len(report.Alerts))
+	if report.Incidents > 0 {
+		fmt.Printf("Incidents detected: %d/%d\n", report.Detected, report.Incidents)
+	}
+	if report.Precision != nil {
+		fmt.Printf("Precision: %.2f (%d/%d alerts matched an incident)\n", *report.Precision, report.Matched, len(report.Alerts))
+	}
+	if report.Recall != nil {
+		fmt.Printf("Recall:    %.2f\n", *report.Recall)
+	}
+}
+
+// replayDetector runs d at every step in (from, to] over its lookback
+// window ending there, as the scheduler would have. A finding the previous
+// run also reported is an ongoing condition: it stays one alert, raised to
+// the highest severity seen while it lasts.
+// Parameters: lookback, plus any the detector reads from its Window.
+func replayDetector(ctx context.Context, d Detector, from, to time.Time, step time.Duration, params Params) ([]Anomaly, error) {
+	schedule := d.Schedule()
+	if step <= 0 {
+		step = schedule.Interval
+	}
+	lookback, err := params.duration("lookback", schedule.Lookback)
+	if err != nil {
+		return nil, fmt.Errorf("lookback: %w", err)
+	}
+
+	alerts := []Anomaly{}
+	firing := map[string]int{} // alert key -> index in alerts
+	for t := from.Add(step); !t.After(to); t = t.Add(step) {
+		result, err := d.Run(ctx, Window{From: t.Add(-lookback), To: t, Params: params})
+		if err != nil {
+			return nil, fmt.Errorf("run at %s: %w", t.Format(time.RFC3339), err)
+		}
+
+		current := map[string]int{}
+		for _, f := range findingAlerts(d.Name(), t, result.Findings) {
+			i, ok := current[f.key]
+			if !ok {
+				i, ok = firing[f.key]
+			}
+			if !ok {
+				alerts = append(alerts, f.alert)
+				i = len(alerts) - 1
+			} else if severityRank[f.alert.Severity] > severityRank[alerts[i].Severity] {
+				alerts[i].Severity = f.alert.Severity
+			}
+			current[f.key] = i
+		}
+		firing = current
+	}
+	return alerts, nil
+}
+
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// replayedAlert is an alert together with the key that identifies it across
+// consecutive runs.
+type replayedAlert struct {
+	key   string
+	alert Anomaly
+}
+
+// findingAlerts converts a detector's findings into alerts. Anomalies are
+// kept as they are and keyed by the hour or event they describe, since
+// their figures change as the window moves. Other findings only alert when
+// they carry a Severity or Warning; they become an alert of the detector's
+// type detected at the run time, keyed by organization, provider and
+// endpoint so the same condition is recognized from one run to the next.
+func findingAlerts(detector string, at time.Time, findings interface{}) []replayedAlert {
+	list := reflect.ValueOf(findings)
+	if list.Kind() != reflect.Slice {
+		return nil
+	}
+
+	alerts := []replayedAlert{}
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		if a, ok := item.Interface().(Anomaly); ok {
+			key := fmt.Sprintf("%d|%s|%s", a.OrganizationID, a.Type, a.Provider)
+			if a.DetectedAt.IsZero() {
+				a.DetectedAt = at
+			} else {
+				key += fmt.Sprintf("|%d", a.DetectedAt.Unix())
+			}
+			alerts = append(alerts, replayedAlert{key: key, alert: a})
+			continue
+		}
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+
+		field := func(name string) string {
+			if v := item.FieldByName(name); v.Kind() == reflect.String {
+				return v.String()
+			}
+			return ""
+		}
+		severity, warning := field("Severity"), field("Warning")
+		if severity == "" && warning == "" {
+			continue
+		}
+
+		a := Anomaly{Type: detector, Provider: field("Provider"), Severity: severity, Description: warning, DetectedAt: at}
+		if scoped, ok := item.Interface().(OrgScoped); ok {
+			a.OrganizationID = scoped.Organization()
+		}
+		endpoint := strings.TrimSpace(field("Method") + " " + field("Endpoint"))
+		if a.Description == "" {
+			a.Description = field("Recommendation")
+		}
+		if a.Description == "" {
+			a.Description = strings.TrimSpace(a.Provider + " " + endpoint)
+		}
+		key := fmt.Sprintf("%d|%s|%s|%s", a.OrganizationID, detector, a.Provider, endpoint)
+		alerts = append(alerts, replayedAlert{key: key, alert: a})
+	}
+	return alerts
+}
+
+// backtestStream replays raw requests through a StreamEngine, evaluating at
+// every bucket boundary as the live engine does. Parameters: short_window,
+// long_window, min_requests, error_ratio, cost_multiplier,
+// latency_multiplier, cooldown.
+func (s *AnalyticsServer) backtestStream(ctx context.Context, from, to time.Time, params Params) ([]Anomaly, error) {
+	cfg := defaultStreamConfig()
+	var err error
+	if cfg.ShortWindow, err = params.duration("short_window", cfg.ShortWindow); err != nil {
+		return nil, fmt.Errorf("short_window: %w", err)
+	}
+	if cfg.LongWindow, err = params.duration("long_window", cfg.LongWindow); err != nil {
+		return nil, fmt.Errorf("long_window: %w", err)
+	}
+	if cfg.Cooldown, err = params.duration("cooldown", cfg.Cooldown); err != nil {
+		return nil, fmt.Errorf("cooldown: %w", err)
+	}
+	if cfg.MinRequests, err = params.int("min_requests", cfg.MinRequests); err != nil {
+		return nil, fmt.Errorf("min_requests: %w", err)
+	}
+	if cfg.ErrorRatio, err = params.float("error_ratio", cfg.ErrorRatio); err != nil {
+		return nil, fmt.Errorf("error_ratio: %w", err)
+	}
+	if cfg.CostMultiplier, err = params.float("cost_multiplier", cfg.CostMultiplier); err != nil {
+		return nil, fmt.Errorf("cost_multiplier: %w", err)
+	}
+	if cfg.LatencyMultiplier, err = params.float("latency_multiplier", cfg.LatencyMultiplier); err != nil {
+		return nil, fmt.Errorf("latency_multiplier: %w", err)
+	}
+
+	engine := NewStreamEngine(cfg)
+
+	// Start one full window early so the first evaluations have a baseline.
+	query := `
+        SELECT
+            organization_id,
+            provider,
+            endpoint,
+            method,
+            COALESCE(status_code, 0),
+            COALESCE(latency_ms, 0),
+            COALESCE(cost, 0),
+            time
+        FROM api_requests
+        WHERE time >= $1 AND time < $2
+        ORDER BY time
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, from.Add(-(cfg.ShortWindow + cfg.LongWindow)), to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []Anomaly{}
+	next := time.Time{}
+	evaluate := func(until time.Time) {
+		for !next.IsZero() && next.Before(until) {
+			if !next.Before(from) {
+				alerts = append(alerts, engine.Evaluate(next)...)
+			}
+			next = next.Add(cfg.BucketWidth)
+		}
+	}
+
+	for rows.Next() {
+		var ev streamEvent
+		var orgID int
+		var t time.Time
+		if err := rows.Scan(&orgID, &ev.Provider, &ev.Endpoint, &ev.Method, &ev.StatusCode, &ev.LatencyMS, &ev.Cost, &t); err != nil {
+			continue
+		}
+
+		if next.IsZero() {
+			next = t.Truncate(cfg.BucketWidth).Add(cfg.BucketWidth)
+		}
+		evaluate(t)
+
+		ev.Type = "new_request"
+		ev.OrganizationID = strconv.Itoa(orgID)
+		ev.Timestamp = t.UnixMilli()
+		engine.Observe(ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	evaluate(to)
+
+	return alerts, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplayDetectorMergesOngoingFindings(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Findings reported by the run ending at each step.
+	runs := map[int][]RateLimitStatus{
+		1: {{OrganizationID: 1, Provider: "Stripe", Severity: "medium", Warning: "Stripe peaked at 85%"}},
+		2: {
+			{OrganizationID: 1, Provider: "Stripe", Severity: "high", Warning: "Stripe peaked at 97%"},
+			{OrganizationID: 2, Provider: "Stripe", Utilization: 40},
+		},
+		4: {{OrganizationID: 1, Provider: "Stripe", Severity: "medium", Warning: "Stripe peaked at 82%"}},
+		5: {{OrganizationID: 1, Provider: "OpenAI", Severity: "medium", Warning: "OpenAI peaked at 81%"}},
+	}
+	d := NewDetector(DetectorSpec{Name: "rate_limits", Interval: time.Minute}, func(ctx context.Context, w Window) ([]RateLimitStatus, error) {
+		return runs[int(w.To.Sub(from)/time.Minute)], nil
+	})
+
+	alerts, err := replayDetector(context.Background(), d, from, from.Add(5*time.Minute), 0, Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		step     int
+		provider string
+		severity string
+	}{
+		{1, "Stripe", "high"},
+		{4, "Stripe", "medium"},
+		{5, "OpenAI", "medium"},
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("replayDetector() returned %d alerts, want %d: %+v", len(alerts), len(want), alerts)
+	}
+	for i, w := range want {
+		a := alerts[i]
+		if !a.DetectedAt.Equal(from.Add(time.Duration(w.step)*time.Minute)) || a.Provider != w.provider || a.Severity != w.severity {
+			t.Errorf("alert %d = %s %s %s, want step %d %s %s", i, a.DetectedAt.Format("15:04"), a.Provider, a.Severity, w.step, w.provider, w.severity)
+		}
+		if a.Type != "rate_limits" || a.OrganizationID != 1 {
+			t.Errorf("alert %d is %s for org %d", i, a.Type, a.OrganizationID)
+		}
+	}
+	if alerts[0].Description != "Stripe peaked at 85%" {
+		t.Errorf("merged alert description = %q", alerts[0].Description)
+	}
+}
+
+func TestFindingAlertsKeys(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	spike := at.Add(-time.Hour)
+	findings := []Anomaly{
+		{OrganizationID: 1, Type: "cost_spike", DetectedAt: spike},
+		{OrganizationID: 1, Type: "error_rate", Provider: "Stripe"},
+	}
+	got := findingAlerts("cost_spikes", at, findings)
+	if len(got) != 2 {
+		t.Fatalf("findingAlerts() returned %d alerts, want 2", len(got))
+	}
+	if !got[0].alert.DetectedAt.Equal(spike) || !got[1].alert.DetectedAt.Equal(at) {
+		t.Errorf("detected at %s and %s, want %s and %s", got[0].alert.DetectedAt, got[1].alert.DetectedAt, spike, at)
+	}
+	// An anomaly without its own time is the same alert on the next run.
+	next := findingAlerts("cost_spikes", at.Add(time.Minute), findings)
+	if next[0].key != got[0].key || next[1].key != got[1].key {
+		t.Errorf("keys changed between runs: %q, %q then %q, %q", got[0].key, got[1].key, next[0].key, next[1].key)
+	}
+
+	deprecated := []DeprecatedEndpointUsage{
+		{OrganizationID: 3, Provider: "Stripe", Method: "GET", Endpoint: "/v1/sources", Severity: "high", Calls8d: 10},
+		{OrganizationID: 3, Provider: "Stripe", Method: "GET", Endpoint: "/v1/sources", Severity: "high", Calls8d: 12},
+		{OrganizationID: 3, Provider: "Stripe", Method: "GET", Endpoint: "/v1/tokens", Severity: "low"},
+	}
+	got = findingAlerts("deprecated_endpoints", at, deprecated)
+	if len(got) != 3 || got[0].key != got[1].key || got[0].key == got[2].key {
+		t.Errorf("findingAlerts() keys = %+v, want the first two equal", got)
+	}
+	if got := findingAlerts("batching", at, []BatchingOpportunity{{}}); len(got) != 0 {
+		t.Errorf("findings without severity produced %d alerts", len(got))
+	}
+}
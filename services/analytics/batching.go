@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// batchableEndpoint describes a provider endpoint that can take many items
+// per request. BatchSize is a realistic size to plan for, not the hard
+// maximum, since payload limits usually bite first.
+type batchableEndpoint struct {
+	Provider  string
+	Method    string
+	Path      string // single-item endpoint, in endpointTemplate form
+	MaxBatch  int
+	BatchSize int
+	Technique string
+}
+
+var batchCatalog = []batchableEndpoint{
+	{"OpenAI", "POST", "/v1/embeddings", 2048, 100, "pass an array of inputs to a single /v1/embeddings call"},
+	{"Stripe", "GET", "/v1/customers/{id}", 100, 100, "use the list endpoint with limit=100 and filters instead of retrieving customers one by one"},
+	{"Stripe", "GET", "/v1/charges/{id}", 100, 100, "use the list endpoint with limit=100 and filters instead of retrieving charges one by one"},
+	{"Stripe", "GET", "/v1/invoices/{id}", 100, 100, "use the list endpoint with limit=100 and filters instead of retrieving invoices one by one"},
+	{"Stripe", "GET", "/v1/subscriptions/{id}", 100, 100, "use the list endpoint with limit=100 and filters instead of retrieving subscriptions one by one"},
+	{"Stripe", "GET", "/v1/payment_intents/{id}", 100, 100, "use the list endpoint with limit=100 and filters instead of retrieving payment intents one by one"},
+	{"SendGrid", "POST", "/v3/mail/send", 1000, 100, "send one request with multiple personalizations instead of one request per recipient"},
+	{"AWS S3", "DELETE", "/bucket/object", 1000, 500, "use DeleteObjects to remove up to 1000 keys per request"},
+}
+
+const (
+	// Calls from one caller no further apart than this form a burst.
+	defaultBatchBurstGap = 2 * time.Second
+	// Bursts shorter than this aren't worth batching.
+	minBatchBurst = 5
+)
+
+type BatchingOpportunity struct {
+	OrganizationID   int     `json:"organization_id"`
+	Provider         string  `json:"provider"`
+	Endpoint         string  `json:"endpoint"`
+	Method           string  `json:"method"`
+	Caller           string  `json:"caller"`
+	Bursts           int     `json:"bursts"`
+	Calls            int     `json:"calls"`
+	AvgBurstSize     float64 `json:"avg_burst_size"`
+	BatchSize        int     `json:"batch_size"`
+	BatchedRequests  int     `json:"batched_requests"`
+	RequestReduction float64 `json:"request_reduction"`
+	CostSaved        float64 `json:"cost_saved"`
+	Recommendation   string  `json:"recommendation"`
+}
+
+func (b BatchingOpportunity) Organization() int { return b.OrganizationID }
+
+// matchBatchable finds the catalog entry for a single-item call. List and
+// search endpoints such as /v1/customers or /v1/customers/search are
+// already batched and never match.
+func matchBatchable(provider, method, endpoint string) (int, bool) {
+	template := endpointTemplate(endpoint)
+	for i, b := range batchCatalog {
+		if b.Provider == provider && b.Method == method && template == b.Path {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// likePrefix is the literal part of a catalog path before its first
+// placeholder, for prefiltering in SQL.
+func likePrefix(path string) string {
+	if i := strings.Index(path, "{"); i >= 0 {
+		path = path[:i]
+	}
+	return path + "%"
+}
+
+// splitBursts groups sorted call times into runs whose gaps are at most gap
+// and returns the sizes of runs with at least minBatchBurst calls.
+func splitBursts(times []time.Time, gap time.Duration) []int {
+	bursts := []int{}
+	size := 0
+	for i, t := range times {
+		if i > 0 && t.Sub(times[i-1]) > gap {
+			if size >= minBatchBurst {
+				bursts = append(bursts, size)
+			}
+			size = 0
+		}
+		size++
+	}
+	if size >= minBatchBurst {
+		bursts = append(bursts, size)
+	}
+	return bursts
+}
+
+// detectBatchingOpportunities finds bursts of single-item calls. Parameters:
+// gap.
+func (s *AnalyticsServer) detectBatchingOpportunities(ctx context.Context, w Window) ([]BatchingOpportunity, error) {
+	gap, err := w.Params.duration("gap", jobs.EnvDuration("BATCH_BURST_GAP", defaultBatchBurstGap))
+	if err != nil {
+		return nil, fmt.Errorf("gap: %w", err)
+	}
+
+	// Restrict the scan to catalog endpoints
+	conditions := []string{}
+	args := []interface{}{w.From, w.To}
+	for _, b := range batchCatalog {
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("(r.provider = $%d AND r.method = $%d AND r.endpoint LIKE $%d)", n+1, n+2, n+3))
+		args = append(args, b.Provider, b.Method, likePrefix(b.Path))
+	}
+
+	query := `
+        SELECT
+            r.organization_id,
+            r.provider,
+            r.method,
+            r.endpoint,
+            ` + callerExpr("r") + ` as caller,
+            r.time,
+            COALESCE(r.cost, 0)
+        FROM api_requests r
+        WHERE
+            r.time >= $1 AND r.time < $2
+            AND (` + strings.Join(conditions, " OR ") + `)
+        ORDER BY r.organization_id, caller, r.provider, r.method, r.time
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type groupKey struct {
+		orgID   int
+		caller  string
+		catalog int
+	}
+	times := map[groupKey][]time.Time{}
+	spent := map[groupKey]float64{}
+	order := []groupKey{}
+
+	for rows.Next() {
+		var orgID int
+		var provider, method, endpoint, caller string
+		var at time.Time
+		var cost float64
+
+		if err := rows.Scan(&orgID, &provider, &method, &endpoint, &caller, &at, &cost); err != nil {
+			continue
+		}
+
+		idx, ok := matchBatchable(provider, method, endpoint)
+		if !ok {
+			continue
+		}
+		key := groupKey{orgID: orgID, caller: caller, catalog: idx}
+		if _, ok := times[key]; !ok {
+			order = append(order, key)
+		}
+		times[key] = append(times[key], at)
+		spent[key] += cost
+	}
+
+	opportunities := []BatchingOpportunity{}
+	for _, key := range order {
+		bursts := splitBursts(times[key], gap)
+		if len(bursts) == 0 {
+			continue
+		}
+
+		entry := batchCatalog[key.catalog]
+		op := BatchingOpportunity{
+			OrganizationID: key.orgID,
+			Provider:       entry.Provider,
+			Endpoint:       entry.Path,
+			Method:         entry.Method,
+			Caller:         key.caller,
+			Bursts:         len(bursts),
+			BatchSize:      entry.BatchSize,
+		}
+		for _, n := range bursts {
+			op.Calls += n
+			op.BatchedRequests += (n + entry.BatchSize - 1) / entry.BatchSize
+		}
+		op.AvgBurstSize = float64(op.Calls) / float64(op.Bursts)
+		op.RequestReduction = 100.0 * float64(op.Calls-op.BatchedRequests) / float64(op.Calls)
+		// Each call saved is priced at the average observed cost of the
+		// group's calls, not the provider's list price
+		avgCost := spent[key] / float64(len(times[key]))
+		op.CostSaved = float64(op.Calls-op.BatchedRequests) * avgCost
+		op.Recommendation = fmt.Sprintf("%s %s is called one item at a time in bursts of %.0f; %s (up to %d per request). Batches of %d would cut %d calls to %d.",
+			entry.Method, entry.Path, op.AvgBurstSize, entry.Technique, entry.MaxBatch, entry.BatchSize, op.Calls, op.BatchedRequests)
+
+		opportunities = append(opportunities, op)
+	}
+
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].CostSaved > opportunities[j].CostSaved
+	})
+
+	return opportunities, rows.Err()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchBatchable(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		method   string
+		endpoint string
+		want     string // catalog path, or "" for no match
+	}{
+		{"stripe retrieve", "Stripe", "GET", "/v1/customers/cus_Nf7aB3kLm9Qx", "/v1/customers/{id}"},
+		{"stripe retrieve with query", "Stripe", "GET", "/v1/charges/ch_3MmlLrLkdIwHu7ix?expand=customer", "/v1/charges/{id}"},
+		{"stripe list", "Stripe", "GET", "/v1/customers", ""},
+		{"stripe list with limit", "Stripe", "GET", "/v1/customers?limit=100", ""},
+		{"stripe search", "Stripe", "GET", "/v1/customers/search", ""},
+		{"stripe create", "Stripe", "POST", "/v1/customers", ""},
+		{"stripe nested item", "Stripe", "GET", "/v1/customers/cus_Nf7aB3kLm9Qx/sources", ""},
+		{"openai embeddings", "OpenAI", "POST", "/v1/embeddings", "/v1/embeddings"},
+		{"sendgrid send", "SendGrid", "POST", "/v3/mail/send", "/v3/mail/send"},
+		{"wrong provider", "OpenAI", "GET", "/v1/customers/cus_Nf7aB3kLm9Qx", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, ok := matchBatchable(tt.provider, tt.method, tt.endpoint)
+			got := ""
+			if ok {
+				got = batchCatalog[idx].Path
+			}
+			if got != tt.want {
+				t.Errorf("matchBatchable(%q, %q, %q) = %q, want %q", tt.provider, tt.method, tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	tests := map[string]string{
+		"/v1/customers/{id}": "/v1/customers/%",
+		"/v1/embeddings":     "/v1/embeddings%",
+	}
+	for path, want := range tests {
+		if got := likePrefix(path); got != want {
+			t.Errorf("likePrefix(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSplitBursts(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds ...float64) []time.Time {
+		times := []time.Time{}
+		for _, s := range seconds {
+			times = append(times, start.Add(time.Duration(s*float64(time.Second))))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  []int
+	}{
+		{"empty", nil, []int{}},
+		{"too short", at(0, 1, 2, 3), []int{}},
+		{"one burst", at(0, 1, 2, 3, 4), []int{5}},
+		{"gap at limit stays in burst", at(0, 2, 4, 6, 8), []int{5}},
+		{"gap over limit splits", at(0, 1, 2, 3, 4, 10, 11, 12, 13, 14, 15), []int{5, 6}},
+		{"short burst dropped", at(0, 1, 2, 10, 11, 12, 13, 14), []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitBursts(tt.times, 2*time.Second)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitBursts() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("splitBursts() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
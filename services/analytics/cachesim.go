@@ -0,0 +1,163 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"time"
+)
+
+// Cache configurations replayed for every candidate endpoint. Sizes are
+// entry counts for an LRU; every size is combined with every TTL.
+var (
+	cacheSimSizes = []int{100, 1000, 10000}
+	cacheSimTTLs  = []time.Duration{30 * time.Second, 5 * time.Minute, time.Hour, 24 * time.Hour}
+)
+
+// A configuration within this fraction of the best hit ratio is considered
+// good enough; the cheapest such configuration is the knee we recommend.
+const cacheKneeFraction = 0.9
+
+// cacheKeyExpr keys a request the way a response cache in front of the
+// provider would: by method and endpoint, plus the query string when the
+// client records one under metadata "query". The rest of the metadata (user,
+// session, team) does not change the response, so it must not split the key.
+const cacheKeyExpr = `MD5(method || ' ' || endpoint || COALESCE('?' || (metadata->>'query'), ''))`
+
+type cachedCall struct {
+	at        time.Time
+	key       string
+	status    int
+	latencyMS int
+	cost      float64
+}
+
+type CacheSimResult struct {
+	Size           int     `json:"size"`
+	TTLSeconds     int     `json:"ttl_seconds"`
+	Requests       int     `json:"requests"`
+	Hits           int     `json:"hits"`
+	HitRatio       float64 `json:"hit_ratio"`
+	LatencySavedMS int64   `json:"latency_saved_ms"`
+	CostSaved      float64 `json:"cost_saved"`
+}
+
+type lruEntry struct {
+	key      string
+	storedAt time.Time
+}
+
+// simulateCache replays calls in time order through an LRU of the given size
+// whose entries expire after ttl. Only successful responses are cached, but
+// any call that finds a fresh entry counts as a hit.
+func simulateCache(calls []cachedCall, size int, ttl time.Duration) CacheSimResult {
+	result := CacheSimResult{
+		Size:       size,
+		TTLSeconds: int(ttl.Seconds()),
+		Requests:   len(calls),
+	}
+
+	order := list.New()
+	entries := map[string]*list.Element{}
+
+	for _, call := range calls {
+		if el, ok := entries[call.key]; ok {
+			entry := el.Value.(*lruEntry)
+			if call.at.Sub(entry.storedAt) <= ttl {
+				order.MoveToFront(el)
+				result.Hits++
+				result.LatencySavedMS += int64(call.latencyMS)
+				result.CostSaved += call.cost
+				continue
+			}
+			order.Remove(el)
+			delete(entries, call.key)
+		}
+
+		if call.status >= 400 {
+			continue
+		}
+
+		entries[call.key] = order.PushFront(&lruEntry{key: call.key, storedAt: call.at})
+		if order.Len() > size {
+			oldest := order.Back()
+			order.Remove(oldest)
+			delete(entries, oldest.Value.(*lruEntry).key)
+		}
+	}
+
+	if result.Requests > 0 {
+		result.HitRatio = 100.0 * float64(result.Hits) / float64(result.Requests)
+	}
+	return result
+}
+
+// simulateCacheGrid runs every size/TTL combination, ordered by increasing
+// TTL and then size, which is also the order of preference for the knee.
+func simulateCacheGrid(calls []cachedCall) []CacheSimResult {
+	results := []CacheSimResult{}
+	for _, ttl := range cacheSimTTLs {
+		for _, size := range cacheSimSizes {
+			results = append(results, simulateCache(calls, size, ttl))
+		}
+	}
+	return results
+}
+
+// cacheKnee picks the first configuration (shortest TTL, then smallest size)
+// reaching cacheKneeFraction of the best hit ratio: past that point extra
+// memory and staleness buy little.
+func cacheKnee(results []CacheSimResult) (CacheSimResult, bool) {
+	best := 0.0
+	for _, r := range results {
+		if r.HitRatio > best {
+			best = r.HitRatio
+		}
+	}
+	if best == 0 {
+		return CacheSimResult{}, false
+	}
+
+	for _, r := range results {
+		if r.HitRatio >= cacheKneeFraction*best {
+			return r, true
+		}
+	}
+	return CacheSimResult{}, false
+}
+
+// loadCacheableCalls returns the window's GET traffic to endpoint in time
+// order, keyed the same way a response cache in front of it would be.
+func (s *AnalyticsServer) loadCacheableCalls(ctx context.Context, w Window, orgID int, endpoint string) ([]cachedCall, error) {
+	query := `
+        SELECT
+            time,
+            ` + cacheKeyExpr + ` as cache_key,
+            COALESCE(status_code, 0),
+            COALESCE(latency_ms, 0),
+            COALESCE(cost, 0)
+        FROM api_requests
+        WHERE
+            method = 'GET'
+            AND endpoint = $1
+            AND organization_id = $2
+            AND time >= $3 AND time < $4
+        ORDER BY time
+        LIMIT 200000
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, endpoint, orgID, w.From, w.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calls := []cachedCall{}
+	for rows.Next() {
+		var c cachedCall
+		if err := rows.Scan(&c.at, &c.key, &c.status, &c.latencyMS, &c.cost); err != nil {
+			continue
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var simStart = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// simCalls builds one 100ms, $0.01 call per "key@seconds" spec; a trailing
+// "!" marks the call as a 503.
+func simCalls(specs ...string) []cachedCall {
+	calls := make([]cachedCall, 0, len(specs))
+	for _, spec := range specs {
+		status := 200
+		if strings.HasSuffix(spec, "!") {
+			status = 503
+			spec = strings.TrimSuffix(spec, "!")
+		}
+		key, at, _ := strings.Cut(spec, "@")
+		seconds, _ := strconv.Atoi(at)
+		calls = append(calls, cachedCall{at: simStart.Add(time.Duration(seconds) * time.Second), key: key, status: status, latencyMS: 100, cost: 0.01})
+	}
+	return calls
+}
+
+func checkHits(t *testing.T, calls []cachedCall, size int, ttl time.Duration, hits int) {
+	t.Helper()
+	got := simulateCache(calls, size, ttl)
+	if got.Requests != len(calls) || got.Hits != hits {
+		t.Fatalf("simulateCache() = %d hits of %d requests, want %d of %d", got.Hits, got.Requests, hits, len(calls))
+	}
+	if got.LatencySavedMS != int64(100*hits) || math.Abs(got.CostSaved-0.01*float64(hits)) > 1e-9 {
+		t.Errorf("simulateCache() saved %dms and $%v for %d hits", got.LatencySavedMS, got.CostSaved, hits)
+	}
+	if len(calls) > 0 {
+		if want := 100.0 * float64(hits) / float64(len(calls)); math.Abs(got.HitRatio-want) > 1e-9 {
+			t.Errorf("HitRatio = %v, want %v", got.HitRatio, want)
+		}
+	}
+}
+
+func TestSimulateCacheTTL(t *testing.T) {
+	checkHits(t, nil, 10, time.Minute, 0)
+	checkHits(t, simCalls("a@0", "a@10", "a@20"), 10, time.Minute, 2)
+	// An entry is still fresh exactly at its TTL.
+	checkHits(t, simCalls("a@0", "a@60"), 10, time.Minute, 1)
+	// Past it, the call misses and stores a fresh entry.
+	checkHits(t, simCalls("a@0", "a@61", "a@62"), 10, time.Minute, 1)
+	checkHits(t, simCalls("a@0", "b@1", "c@2"), 10, time.Minute, 0)
+}
+
+func TestSimulateCacheErrors(t *testing.T) {
+	// Failed responses are never stored...
+	checkHits(t, simCalls("a@0!", "a@1", "a@2"), 10, time.Minute, 1)
+	// ...but a call that failed upstream is still served from a stored entry.
+	checkHits(t, simCalls("a@0", "a@1!"), 10, time.Minute, 1)
+}
+
+func TestSimulateCacheEviction(t *testing.T) {
+	checkHits(t, simCalls("a@0", "b@1", "a@2"), 1, time.Minute, 0)
+	// The hit on a at 2s makes b the eviction victim when c arrives.
+	checkHits(t, simCalls("a@0", "b@1", "a@2", "c@3", "a@4"), 2, time.Minute, 2)
+}
+
+func TestCacheKnee(t *testing.T) {
+	curve := func(pairs ...float64) []CacheSimResult {
+		results := []CacheSimResult{}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			results = append(results, CacheSimResult{Size: int(pairs[i]), HitRatio: pairs[i+1]})
+		}
+		return results
+	}
+
+	cases := map[string]struct {
+		results []CacheSimResult
+		size    int // 0 when no knee is expected
+	}{
+		"no results":                    {curve(), 0},
+		"no hits":                       {curve(100, 0, 1000, 0), 0},
+		"best is first":                 {curve(100, 50, 1000, 40), 100},
+		"first within fraction of best": {curve(100, 20, 1000, 45, 10000, 50), 1000},
+		"just below fraction":           {curve(100, 44.9, 1000, 50), 1000},
+	}
+	for name, c := range cases {
+		got, ok := cacheKnee(c.results)
+		if ok != (c.size != 0) || got.Size != c.size {
+			t.Errorf("%s: cacheKnee() = size %d, %v; want size %d", name, got.Size, ok, c.size)
+		}
+	}
+}
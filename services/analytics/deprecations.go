@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deprecation is a provider endpoint that has been or will be retired. Path
+// is matched as a prefix of the endpoint template, so IDs appear as {id}.
+// Deadline is the announced shutdown date (YYYY-MM-DD), empty if none.
+type deprecation struct {
+	Provider    string `json:"provider"`
+	Method      string `json:"method,omitempty"` // empty matches any method
+	Path        string `json:"path"`
+	Replacement string `json:"replacement"`
+	Deadline    string `json:"deadline,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+}
+
+var deprecationCatalog = []deprecation{
+	{Provider: "OpenAI", Path: "/v1/completions", Replacement: "/v1/chat/completions", Deadline: "2024-01-04",
+		Notes: "legacy completion models were shut down; only gpt-3.5-turbo-instruct remains on this endpoint"},
+	{Provider: "OpenAI", Path: "/v1/edits", Replacement: "/v1/chat/completions", Deadline: "2024-01-04"},
+	{Provider: "OpenAI", Path: "/v1/engines", Replacement: "/v1/models"},
+	{Provider: "Twilio", Path: "/2010-04-01/Accounts/{id}/SMS/Messages", Replacement: "/2010-04-01/Accounts/{id}/Messages"},
+	{Provider: "Twilio", Path: "/2010-04-01/Accounts/{id}/SMS/ShortCodes", Replacement: "/2010-04-01/Accounts/{id}/IncomingPhoneNumbers"},
+	{Provider: "SendGrid", Path: "/api/mail.send", Replacement: "/v3/mail/send", Notes: "v2 Web API"},
+	{Provider: "Stripe", Path: "/v1/recipients", Replacement: "/v1/accounts", Notes: "use Connect accounts for payouts"},
+}
+
+const (
+	// Zombie endpoints receive at most this fraction of their usual daily
+	// traffic over the last 24 hours.
+	zombieTrafficRatio = 0.1
+	// Endpoints averaging fewer calls a day than this are too quiet to judge.
+	minZombieDailyCalls = 20
+	// Callers listed per flagged endpoint.
+	maxEndpointCallers = 10
+)
+
+type EndpointCaller struct {
+	Caller   string    `json:"caller"`
+	Calls    int       `json:"calls"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type DeprecatedEndpointUsage struct {
+	OrganizationID    int              `json:"organization_id"`
+	Provider          string           `json:"provider"`
+	Method            string           `json:"method"`
+	Endpoint          string           `json:"endpoint"`
+	Replacement       string           `json:"replacement"`
+	Deadline          string           `json:"deadline,omitempty"`
+	DaysUntilDeadline *int             `json:"days_until_deadline,omitempty"`
+	Calls8d           int              `json:"calls_8d"`
+	LastSeen          time.Time        `json:"last_seen"`
+	Callers           []EndpointCaller `json:"callers"`
+	Severity          string           `json:"severity"`
+	Recommendation    string           `json:"recommendation"`
+}
+
+func (d DeprecatedEndpointUsage) Organization() int { return d.OrganizationID }
+
+type ZombieEndpoint struct {
+	OrganizationID int              `json:"organization_id"`
+	Provider       string           `json:"provider"`
+	Method         string           `json:"method"`
+	Endpoint       string           `json:"endpoint"`
+	Calls24h       int              `json:"calls_24h"`
+	DailyAvg7d     float64          `json:"daily_avg_7d"`
+	TrafficRatio   float64          `json:"traffic_ratio"`
+	Callers        []EndpointCaller `json:"callers"`
+	Deprecated     bool             `json:"deprecated"`
+	Deadline       string           `json:"deadline,omitempty"`
+	Recommendation string           `json:"recommendation"`
+}
+
+func (z ZombieEndpoint) Organization() int { return z.OrganizationID }
+
+// loadDeprecations returns the built-in catalog plus any entries from the
+// JSON file named by DEPRECATIONS_FILE.
+func loadDeprecations() []deprecation {
+	catalog := append([]deprecation{}, deprecationCatalog...)
+
+	path := os.Getenv("DEPRECATIONS_FILE")
+	if path == "" {
+		return catalog
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", path, err)
+		return catalog
+	}
+	var extra []deprecation
+	if err := json.Unmarshal(data, &extra); err != nil {
+		log.Printf("Failed to parse %s: %v", path, err)
+		return catalog
+	}
+	return append(catalog, extra...)
+}
+
+func matchDeprecation(catalog []deprecation, provider, method, template string) (deprecation, bool) {
+	for _, d := range catalog {
+		if d.Provider != provider || (d.Method != "" && d.Method != method) {
+			continue
+		}
+		if template == d.Path || strings.HasPrefix(template, d.Path+"/") || strings.HasPrefix(template, d.Path+".") {
+			return d, true
+		}
+	}
+	return deprecation{}, false
+}
+
+// endpointUsage is one endpoint template's traffic, split into the last 24
+// hours of the window and the days before that.
+type endpointUsage struct {
+	orgID    int
+	provider string
+	method   string
+	template string
+	recent   int
+	prior    int
+	lastSeen time.Time
+	callers  map[string]*EndpointCaller
+}
+
+func (u *endpointUsage) topCallers() []EndpointCaller {
+	callers := []EndpointCaller{}
+	for _, c := range u.callers {
+		callers = append(callers, *c)
+	}
+	sort.Slice(callers, func(i, j int) bool {
+		if callers[i].Calls != callers[j].Calls {
+			return callers[i].Calls > callers[j].Calls
+		}
+		return callers[i].Caller < callers[j].Caller
+	})
+	if len(callers) > maxEndpointCallers {
+		callers = callers[:maxEndpointCallers]
+	}
+	return callers
+}
+
+// loadEndpointUsage aggregates the window's traffic by endpoint template.
+// Calls in its last 24 hours are recent; callers only count those when
+// recentOnly.
+func (s *AnalyticsServer) loadEndpointUsage(ctx context.Context, w Window, recentOnly bool) ([]*endpointUsage, error) {
+	query := `
+        SELECT
+            organization_id,
+            provider,
+            method,
+            endpoint,
+            ` + callerExpr("") + ` as caller,
+            COUNT(*) FILTER (WHERE time >= $3) as recent,
+            COUNT(*) FILTER (WHERE time < $3) as prior,
+            MAX(time) as last_seen
+        FROM api_requests
+        WHERE time >= $1 AND time < $2
+        GROUP BY organization_id, provider, method, endpoint, caller
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To, w.To.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type usageKey struct {
+		orgID    int
+		provider string
+		method   string
+		template string
+	}
+	usage := map[usageKey]*endpointUsage{}
+	order := []usageKey{}
+
+	for rows.Next() {
+		var orgID, recent, prior int
+		var provider, method, endpoint, caller string
+		var lastSeen time.Time
+		if err := rows.Scan(&orgID, &provider, &method, &endpoint, &caller, &recent, &prior, &lastSeen); err != nil {
+			continue
+		}
+
+		key := usageKey{orgID, provider, method, endpointTemplate(endpoint)}
+		u, ok := usage[key]
+		if !ok {
+			u = &endpointUsage{
+				orgID:    orgID,
+				provider: provider,
+				method:   method,
+				template: key.template,
+				callers:  map[string]*EndpointCaller{},
+			}
+			usage[key] = u
+			order = append(order, key)
+		}
+		u.recent += recent
+		u.prior += prior
+		if lastSeen.After(u.lastSeen) {
+			u.lastSeen = lastSeen
+		}
+
+		calls := recent + prior
+		if recentOnly {
+			calls = recent
+		}
+		if calls == 0 {
+			continue
+		}
+		c, ok := u.callers[caller]
+		if !ok {
+			c = &EndpointCaller{Caller: caller}
+			u.callers[caller] = c
+		}
+		c.Calls += calls
+		if lastSeen.After(c.LastSeen) {
+			c.LastSeen = lastSeen
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*endpointUsage, 0, len(order))
+	for _, key := range order {
+		result = append(result, usage[key])
+	}
+	return result, nil
+}
+
+// deprecationSeverity is high once the deadline is within 30 days or past,
+// medium within 90 days, and low when no date has been announced.
+func deprecationSeverity(deadline string, now time.Time) (string, *int) {
+	if deadline == "" {
+		return "low", nil
+	}
+	d, err := time.Parse("2006-01-02", deadline)
+	if err != nil {
+		return "low", nil
+	}
+	days := int(math.Floor(d.Sub(now).Hours() / 24))
+	switch {
+	case days <= 30:
+		return "high", &days
+	case days <= 90:
+		return "medium", &days
+	default:
+		return "low", &days
+	}
+}
+
+func (s *AnalyticsServer) detectDeprecatedEndpoints(ctx context.Context, w Window) ([]DeprecatedEndpointUsage, error) {
+	catalog := loadDeprecations()
+	usage, err := s.loadEndpointUsage(ctx, w, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.To
+	findings := []DeprecatedEndpointUsage{}
+	for _, u := range usage {
+		d, ok := matchDeprecation(catalog, u.provider, u.method, u.template)
+		if !ok {
+			continue
+		}
+
+		calls := u.recent + u.prior
+		if calls == 0 {
+			continue
+		}
+
+		severity, days := deprecationSeverity(d.Deadline, now)
+		callers := u.topCallers()
+
+		deadline := "no shutdown date has been announced"
+		if days != nil && *days < 0 {
+			deadline = fmt.Sprintf("it was retired on %s and may fail at any time", d.Deadline)
+		} else if days != nil {
+			deadline = fmt.Sprintf("it shuts down on %s (%d days)", d.Deadline, *days)
+		}
+		recommendation := fmt.Sprintf("%s %s is deprecated and %s. Migrate %d caller(s) to %s.",
+			u.method, u.template, deadline, len(u.callers), d.Replacement)
+		if d.Notes != "" {
+			recommendation += " Note: " + d.Notes + "."
+		}
+
+		findings = append(findings, DeprecatedEndpointUsage{
+			OrganizationID:    u.orgID,
+			Provider:          u.provider,
+			Method:            u.method,
+			Endpoint:          u.template,
+			Replacement:       d.Replacement,
+			Deadline:          d.Deadline,
+			DaysUntilDeadline: days,
+			Calls8d:           calls,
+			LastSeen:          u.lastSeen,
+			Callers:           callers,
+			Severity:          severity,
+			Recommendation:    recommendation,
+		})
+	}
+
+	severityRank := map[string]int{"high": 0, "medium": 1, "low": 2}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return severityRank[findings[i].Severity] < severityRank[findings[j].Severity]
+		}
+		return findings[i].Calls8d > findings[j].Calls8d
+	})
+
+	return findings, nil
+}
+
+// zombieTraffic compares an endpoint's last 24 hours with its daily average
+// over the priorDays before them. It reports whether traffic fell to a
+// trickle of an average busy enough to judge.
+func zombieTraffic(recent, prior int, priorDays float64) (dailyAvg, ratio float64, zombie bool) {
+	if priorDays <= 0 || recent == 0 {
+		return 0, 0, false
+	}
+	dailyAvg = float64(prior) / priorDays
+	if dailyAvg < minZombieDailyCalls {
+		return dailyAvg, 0, false
+	}
+	ratio = float64(recent) / dailyAvg
+	return dailyAvg, ratio, ratio <= zombieTrafficRatio
+}
+
+// detectZombieEndpoints flags endpoints whose last 24 hours of traffic fell
+// to a trickle of their daily average over the rest of the window. The
+// callers still using them are usually a forgotten job or an old client
+// version.
+func (s *AnalyticsServer) detectZombieEndpoints(ctx context.Context, w Window) ([]ZombieEndpoint, error) {
+	catalog := loadDeprecations()
+	usage, err := s.loadEndpointUsage(ctx, w, true)
+	if err != nil {
+		return nil, err
+	}
+
+	priorDays := (w.To.Sub(w.From) - 24*time.Hour).Hours() / 24
+	zombies := []ZombieEndpoint{}
+	for _, u := range usage {
+		dailyAvg, ratio, zombie := zombieTraffic(u.recent, u.prior, priorDays)
+		if !zombie {
+			continue
+		}
+
+		z := ZombieEndpoint{
+			OrganizationID: u.orgID,
+			Provider:       u.provider,
+			Method:         u.method,
+			Endpoint:       u.template,
+			Calls24h:       u.recent,
+			DailyAvg7d:     dailyAvg,
+			TrafficRatio:   ratio,
+			Callers:        u.topCallers(),
+		}
+
+		z.Recommendation = fmt.Sprintf("%s %s dropped to %d calls in 24h from %.0f/day. Finish migrating the remaining %d caller(s) or retire the integration.",
+			u.method, u.template, u.recent, dailyAvg, len(u.callers))
+		if d, ok := matchDeprecation(catalog, u.provider, u.method, u.template); ok {
+			z.Deprecated = true
+			z.Deadline = d.Deadline
+			z.Recommendation += fmt.Sprintf(" The endpoint is deprecated in favour of %s.", d.Replacement)
+		}
+
+		zombies = append(zombies, z)
+	}
+
+	sort.Slice(zombies, func(i, j int) bool {
+		return zombies[i].TrafficRatio < zombies[j].TrafficRatio
+	})
+
+	return zombies, nil
+}
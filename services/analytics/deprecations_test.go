@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchDeprecation(t *testing.T) {
+	catalog := []deprecation{
+		{Provider: "OpenAI", Path: "/v1/completions", Replacement: "/v1/chat/completions"},
+		{Provider: "Twilio", Path: "/2010-04-01/Accounts/{id}/SMS/Messages", Replacement: "/2010-04-01/Accounts/{id}/Messages"},
+		{Provider: "SendGrid", Path: "/api/mail.send", Replacement: "/v3/mail/send"},
+		{Provider: "Stripe", Method: "DELETE", Path: "/v1/customers/{id}/sources", Replacement: "/v1/payment_methods/{id}/detach"},
+	}
+
+	tests := []struct {
+		name        string
+		provider    string
+		method      string
+		template    string
+		replacement string // empty when nothing matches
+	}{
+		{"exact path", "OpenAI", "POST", "/v1/completions", "/v1/chat/completions"},
+		{"sub-resource", "Twilio", "GET", "/2010-04-01/Accounts/{id}/SMS/Messages/{id}", "/2010-04-01/Accounts/{id}/Messages"},
+		{"format suffix", "SendGrid", "POST", "/api/mail.send.json", "/v3/mail/send"},
+		{"path prefix without a separator", "OpenAI", "POST", "/v1/completions_v2", ""},
+		{"replacement path", "OpenAI", "POST", "/v1/chat/completions", ""},
+		{"other provider", "Anthropic", "POST", "/v1/completions", ""},
+		{"method restricted entry", "Stripe", "DELETE", "/v1/customers/{id}/sources/{id}", "/v1/payment_methods/{id}/detach"},
+		{"other method", "Stripe", "GET", "/v1/customers/{id}/sources", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := matchDeprecation(catalog, tt.provider, tt.method, tt.template)
+			if ok != (tt.replacement != "") {
+				t.Fatalf("matchDeprecation() matched = %v, want %v", ok, tt.replacement != "")
+			}
+			if ok && d.Replacement != tt.replacement {
+				t.Errorf("replacement = %q, want %q", d.Replacement, tt.replacement)
+			}
+		})
+	}
+}
+
+func TestZombieTraffic(t *testing.T) {
+	tests := []struct {
+		name      string
+		recent    int
+		prior     int
+		priorDays float64
+		dailyAvg  float64
+		ratio     float64
+		zombie    bool
+	}{
+		{"default 8-day window", 10, 700, 7, 100, 0.1, true},
+		{"above the ratio", 11, 700, 7, 100, 0.11, false},
+		{"steady traffic", 100, 700, 7, 100, 1, false},
+		{"longer lookback", 10, 2900, 29, 100, 0.1, true},
+		{"same calls over a longer lookback are quieter", 10, 700, 29, 700.0 / 29, 10 / (700.0 / 29), false},
+		{"too quiet to judge", 1, 133, 7, 19, 0, false},
+		{"no recent calls", 0, 700, 7, 0, 0, false},
+		{"window without prior days", 5, 0, 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dailyAvg, ratio, zombie := zombieTraffic(tt.recent, tt.prior, tt.priorDays)
+			if zombie != tt.zombie {
+				t.Errorf("zombie = %v, want %v", zombie, tt.zombie)
+			}
+			if math.Abs(dailyAvg-tt.dailyAvg) > 1e-9 || math.Abs(ratio-tt.ratio) > 1e-9 {
+				t.Errorf("dailyAvg, ratio = %v, %v, want %v, %v", dailyAvg, ratio, tt.dailyAvg, tt.ratio)
+			}
+		})
+	}
+}
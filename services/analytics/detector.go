@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// Detector is one independently scheduled analysis. Run returns the
+// detector's findings for w; the scheduler publishes them under Key.
+type Detector interface {
+	Name() string
+	Schedule() Schedule
+	Inputs() []string
+	Run(ctx context.Context, w Window) (Result, error)
+}
+
+type Schedule struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	Lookback time.Duration
+}
+
+// Window is the range of requests one detector run analyzes, [From, To).
+// Live runs end at the current time; backtests replay past windows and may
+// override detector settings through Params.
+type Window struct {
+	From   time.Time
+	To     time.Time
+	Params Params
+}
+
+func (w Window) Span() time.Duration { return w.To.Sub(w.From) }
+
+// Params holds detector setting overrides as key=value strings.
+type Params map[string]string
+
+func (p Params) String() string { return fmt.Sprint(map[string]string(p)) }
+
+func (p Params) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	p[key] = val
+	return nil
+}
+
+func (p Params) float(key string, def float64) (float64, error) {
+	if v, ok := p[key]; ok {
+		return strconv.ParseFloat(v, 64)
+	}
+	return def, nil
+}
+
+func (p Params) int(key string, def int) (int, error) {
+	if v, ok := p[key]; ok {
+		return strconv.Atoi(v)
+	}
+	return def, nil
+}
+
+func (p Params) duration(key string, def time.Duration) (time.Duration, error) {
+	if v, ok := p[key]; ok {
+		return time.ParseDuration(v)
+	}
+	return def, nil
+}
+
+// Result carries a detector's findings. Findings holds the detector's own
+// typed slice, e.g. []Anomaly, and is published as JSON.
+type Result struct {
+	Key      string
+	TTL      time.Duration
+	Findings interface{}
+	Count    int
+
+	// ByOrg splits OrgScoped findings by organization; Empty is published
+	// for organizations without any.
+	ByOrg map[int]interface{}
+	Empty interface{}
+}
+
+// OrgScoped findings belong to one organization. They are published under
+// a key per organization so tenants never see each other's findings.
+type OrgScoped interface {
+	Organization() int
+}
+
+// DetectorSpec describes a detector built with NewDetector.
+type DetectorSpec struct {
+	Name     string
+	Key      string        // Redis key findings are published under
+	Inputs   []string      // tables or feeds the detector reads
+	Interval time.Duration // defaults to ANALYSIS_INTERVAL, or 1 minute; DETECTOR_<NAME>_INTERVAL overrides
+	Timeout  time.Duration // defaults to, and is capped at, Interval
+	TTL      time.Duration // defaults to 10 minutes, and at least two intervals
+	Lookback time.Duration // span of requests each run analyzes; defaults to 1 hour
+}
+
+type typedDetector[T any] struct {
+	spec DetectorSpec
+	run  func(ctx context.Context, w Window) ([]T, error)
+}
+
+// NewDetector adapts a function returning typed findings into a Detector.
+func NewDetector[T any](spec DetectorSpec, run func(ctx context.Context, w Window) ([]T, error)) Detector {
+	if spec.Interval == 0 {
+		spec.Interval = jobs.EnvDuration("ANALYSIS_INTERVAL", time.Minute)
+	}
+	spec.Interval = jobs.EnvDuration("DETECTOR_"+strings.ToUpper(spec.Name)+"_INTERVAL", spec.Interval)
+	if spec.Timeout == 0 || spec.Timeout > spec.Interval {
+		spec.Timeout = spec.Interval
+	}
+	if spec.TTL == 0 {
+		spec.TTL = 10 * time.Minute
+	}
+	if spec.TTL < 2*spec.Interval {
+		spec.TTL = 2 * spec.Interval
+	}
+	if spec.Lookback == 0 {
+		spec.Lookback = time.Hour
+	}
+	return &typedDetector[T]{spec: spec, run: run}
+}
+
+func (d *typedDetector[T]) Name() string     { return d.spec.Name }
+func (d *typedDetector[T]) Inputs() []string { return d.spec.Inputs }
+func (d *typedDetector[T]) Schedule() Schedule {
+	return Schedule{Interval: d.spec.Interval, Timeout: d.spec.Timeout, Lookback: d.spec.Lookback}
+}
+
+func (d *typedDetector[T]) Run(ctx context.Context, w Window) (Result, error) {
+	findings, err := d.run(ctx, w)
+	if err != nil {
+		return Result{}, err
+	}
+	if findings == nil {
+		findings = []T{}
+	}
+	result := Result{Key: d.spec.Key, TTL: d.spec.TTL, Findings: findings, Count: len(findings)}
+
+	var zero T
+	if _, ok := any(zero).(OrgScoped); ok {
+		result.ByOrg = map[int]interface{}{}
+		result.Empty = []T{}
+		for _, f := range findings {
+			orgID := any(f).(OrgScoped).Organization()
+			list, _ := result.ByOrg[orgID].([]T)
+			result.ByOrg[orgID] = append(list, f)
+		}
+	}
+	return result, nil
+}
+
+// Registry holds the detectors the scheduler runs, keyed by name.
+type Registry struct {
+	mu        sync.RWMutex
+	detectors map[string]Detector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{detectors: map[string]Detector{}}
+}
+
+func (r *Registry) Register(d Detector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.detectors[d.Name()]; ok {
+		return fmt.Errorf("detector %q already registered", d.Name())
+	}
+	r.detectors[d.Name()] = d
+	return nil
+}
+
+func (r *Registry) Get(name string) (Detector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.detectors[name]
+	return d, ok
+}
+
+// Detectors returns all registered detectors sorted by name.
+func (r *Registry) Detectors() []Detector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Detector, 0, len(r.detectors))
+	for _, d := range r.detectors {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// registerBuiltinDetectors adds the detectors that ship with the service.
+func (s *AnalyticsServer) registerBuiltinDetectors(r *Registry) {
+	builtins := []Detector{
+		NewDetector(DetectorSpec{
+			Name:    "duplicates",
+			Key:     "analytics:duplicates",
+			Inputs:  []string{"api_requests"},
+			Timeout: 30 * time.Second,
+		}, s.detectDuplicates),
+		NewDetector(DetectorSpec{
+			Name:     "cache_opportunities",
+			Key:      "analytics:cache_recommendations",
+			Inputs:   []string{"api_requests"},
+			Interval: 5 * time.Minute,
+			Timeout:  2 * time.Minute,
+			TTL:      15 * time.Minute,
+			Lookback: 24 * time.Hour,
+		}, s.analyzeCacheOpportunities),
+		// Hourly spikes against the four-week seasonal baseline. Sudden
+		// error, cost and latency changes are caught within seconds by the
+		// streaming engine, so this and error_classes run on long intervals.
+		NewDetector(DetectorSpec{
+			Name:     "cost_spikes",
+			Key:      "analytics:anomalies",
+			Inputs:   []string{"api_costs_hourly", "api_requests", "anomaly_settings"},
+			Interval: 15 * time.Minute,
+			Timeout:  2 * time.Minute,
+			TTL:      45 * time.Minute,
+			Lookback: 24 * time.Hour,
+		}, s.detectAnomalies),
+		NewDetector(DetectorSpec{
+			Name:    "rate_limits",
+			Key:     "analytics:rate_limits",
+			Inputs:  []string{"api_requests", "api_providers"},
+			Timeout: 30 * time.Second,
+		}, s.monitorRateLimits),
+		NewDetector(DetectorSpec{
+			Name:    "retry_storms",
+			Key:     "analytics:retry_storms",
+			Inputs:  []string{"api_requests"},
+			Timeout: 30 * time.Second,
+		}, s.detectRetryStorms),
+		NewDetector(DetectorSpec{
+			Name:    "batching",
+			Key:     "analytics:batching_opportunities",
+			Inputs:  []string{"api_requests", "api_providers"},
+			Timeout: 30 * time.Second,
+		}, s.detectBatchingOpportunities),
+		NewDetector(DetectorSpec{
+			Name:    "n_plus_one",
+			Key:     "analytics:n_plus_one",
+			Inputs:  []string{"api_requests"},
+			Timeout: 30 * time.Second,
+		}, s.detectNPlusOne),
+		NewDetector(DetectorSpec{
+			Name:     "deprecated_endpoints",
+			Key:      "analytics:deprecated_endpoints",
+			Inputs:   []string{"api_requests"},
+			Interval: time.Hour,
+			Timeout:  2 * time.Minute,
+			TTL:      3 * time.Hour,
+			Lookback: 8 * 24 * time.Hour,
+		}, s.detectDeprecatedEndpoints),
+		NewDetector(DetectorSpec{
+			Name:     "zombie_endpoints",
+			Key:      "analytics:zombie_endpoints",
+			Inputs:   []string{"api_requests"},
+			Interval: time.Hour,
+			Timeout:  2 * time.Minute,
+			TTL:      3 * time.Hour,
+			Lookback: 8 * 24 * time.Hour,
+		}, s.detectZombieEndpoints),
+		NewDetector(DetectorSpec{
+			Name:     "error_classes",
+			Key:      "analytics:error_breakdown",
+			Inputs:   []string{"api_requests"},
+			Interval: 15 * time.Minute,
+			Timeout:  2 * time.Minute,
+			TTL:      45 * time.Minute,
+			Lookback: 48 * time.Hour,
+		}, s.detectErrorClasses),
+		NewDetector(DetectorSpec{
+			Name:     "payload_sizes",
+			Key:      "analytics:payload_recommendations",
+			Inputs:   []string{"api_requests"},
+			Interval: 15 * time.Minute,
+			Timeout:  2 * time.Minute,
+			TTL:      45 * time.Minute,
+			Lookback: 24 * time.Hour,
+		}, s.detectPayloadOptimizations),
+	}
+
+	for _, d := range builtins {
+		if err := r.Register(d); err != nil {
+			log.Printf("Failed to register detector: %v", err)
+		}
+	}
+}
+
+// runDetectors starts one scheduled job per detector so a slow or failing
+// detector never delays the others.
+func (s *AnalyticsServer) runDetectors(r *Registry) {
+	for _, d := range r.Detectors() {
+		schedule := d.Schedule()
+		go s.jobs.Every(d.Name(), schedule.Interval, schedule.Timeout, func(ctx context.Context) error {
+			return s.runDetector(ctx, d)
+		})
+	}
+}
+
+// runDetector runs d once and publishes its findings.
+func (s *AnalyticsServer) runDetector(ctx context.Context, d Detector) error {
+	start := time.Now()
+
+	result, err := d.Run(ctx, Window{From: start.Add(-d.Schedule().Lookback), To: start})
+	if err != nil {
+		return err
+	}
+
+	if result.ByOrg != nil {
+		if err := s.orgs.Publish(ctx, result.Key, result.ByOrg, result.Empty, result.TTL); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.Marshal(result.Findings)
+		if err != nil {
+			return err
+		}
+		if err := s.redis.Set(ctx, result.Key, data, result.TTL).Err(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Detector %s found %d results in %s", d.Name(), result.Count, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"github.com/yourusername/api-observatory/shared/tenant"
+)
+
+func newDetectorServer(t *testing.T) (*AnalyticsServer, *miniredis.Miniredis, sqlmock.Sqlmock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &AnalyticsServer{db: db, redis: rdb, jobs: jobs.NewScheduler(rdb, "analytics"), orgs: tenant.NewPublisher(db, rdb)}, mr, mock
+}
+
+func TestRunDetectorPublishesPerOrganization(t *testing.T) {
+	s, mr, mock := newDetectorServer(t)
+	mock.ExpectQuery(`SELECT id FROM organizations`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	var window Window
+	r := NewRegistry()
+	err := r.Register(NewDetector(DetectorSpec{Name: "rate_limits", Key: "test:rate_limits", Interval: time.Minute, Lookback: 30 * time.Minute},
+		func(ctx context.Context, w Window) ([]RateLimitStatus, error) {
+			window = w
+			return []RateLimitStatus{
+				{OrganizationID: 1, Provider: "Stripe"},
+				{OrganizationID: 3, Provider: "Stripe"},
+				{OrganizationID: 1, Provider: "OpenAI"},
+			}, nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(NewDetector(DetectorSpec{Name: "rate_limits"}, func(context.Context, Window) ([]RateLimitStatus, error) { return nil, nil })); err == nil {
+		t.Error("registering a detector name twice succeeded")
+	}
+
+	d, ok := r.Get("rate_limits")
+	if !ok {
+		t.Fatal("registered detector not found")
+	}
+	if err := s.runDetector(context.Background(), d); err != nil {
+		t.Fatalf("runDetector: %v", err)
+	}
+
+	if window.Span() != 30*time.Minute {
+		t.Errorf("detector ran over %s, want its 30m lookback", window.Span())
+	}
+	if mr.Exists("test:rate_limits") {
+		t.Error("org-scoped findings published under the shared key")
+	}
+
+	tests := []struct {
+		orgID     int
+		providers []string
+	}{
+		{1, []string{"Stripe", "OpenAI"}},
+		{2, []string{}},
+		{3, []string{"Stripe"}},
+	}
+	for _, tt := range tests {
+		key := tenant.Key("test:rate_limits", tt.orgID)
+		data, err := mr.Get(key)
+		if err != nil {
+			t.Errorf("%s: %v", key, err)
+			continue
+		}
+		var got []RateLimitStatus
+		if err := json.Unmarshal([]byte(data), &got); err != nil || got == nil {
+			t.Errorf("%s = %s, want a JSON list", key, data)
+			continue
+		}
+		if len(got) != len(tt.providers) {
+			t.Errorf("%s has %d findings, want %d", key, len(got), len(tt.providers))
+			continue
+		}
+		for i, f := range got {
+			if f.OrganizationID != tt.orgID || f.Provider != tt.providers[i] {
+				t.Errorf("%s finding %d = org %d %s, want org %d %s", key, i, f.OrganizationID, f.Provider, tt.orgID, tt.providers[i])
+			}
+		}
+		// The default TTL, already more than two intervals
+		if ttl := mr.TTL(key); ttl != 10*time.Minute {
+			t.Errorf("%s TTL = %s, want 10m", key, ttl)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDetectorPublishesSharedFindings(t *testing.T) {
+	s, mr, mock := newDetectorServer(t)
+
+	type providerHealth struct {
+		Provider string `json:"provider"`
+	}
+	d := NewDetector(DetectorSpec{Name: "health", Key: "test:health", Interval: time.Minute},
+		func(context.Context, Window) ([]providerHealth, error) {
+			return []providerHealth{{Provider: "Stripe"}}, nil
+		})
+	if err := s.runDetector(context.Background(), d); err != nil {
+		t.Fatalf("runDetector: %v", err)
+	}
+
+	data, err := mr.Get("test:health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != `[{"provider":"Stripe"}]` {
+		t.Errorf("test:health = %s", data)
+	}
+	if keys := mr.Keys(); len(keys) != 1 {
+		t.Errorf("published %v, want only test:health", keys)
+	}
+	// Shared findings never look up the organizations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	uuidSegment     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	prefixedID      = regexp.MustCompile(`^[a-z]{2,6}_[A-Za-z0-9]{6,}$`) // Stripe style: cus_..., pi_...
+	twilioSID       = regexp.MustCompile(`^[A-Z]{2}[0-9a-f]{32}$`)
+	numericSegment  = regexp.MustCompile(`^[0-9]+$`)
+	longOpaqueToken = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+	dateSegment     = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+)
+
+// endpointTemplate replaces path segments that look like resource IDs with
+// {id}, so /v1/customers/cus_123 and /v1/customers/cus_456 group together.
+// Version and date segments such as v1 or 2010-04-01 are kept.
+func endpointTemplate(endpoint string) string {
+	path := endpoint
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if isIDSegment(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isIDSegment(seg string) bool {
+	if seg == "" || dateSegment.MatchString(seg) {
+		return false
+	}
+	if numericSegment.MatchString(seg) || uuidSegment.MatchString(seg) ||
+		prefixedID.MatchString(seg) || twilioSID.MatchString(seg) {
+		return true
+	}
+	return longOpaqueToken.MatchString(seg) && strings.ContainsAny(seg, "0123456789")
+}
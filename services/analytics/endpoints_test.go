@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/v1/customers/cus_Nf7aB3kLm9Qx":                                        "/v1/customers/{id}",
+		"/v1/charges/ch_3MmlLrLkdIwHu7ix?expand=customer":                       "/v1/charges/{id}",
+		"/v1/customers/search":                                                  "/v1/customers/search",
+		"/v1/embeddings":                                                        "/v1/embeddings",
+		"/users/42/repos#readme":                                                "/users/{id}/repos",
+		"/items/3f2b8c1e-9a4d-4e5f-8b7a-1c2d3e4f5a6b":                           "/items/{id}",
+		"/2010-04-01/Accounts/AC0123456789abcdef0123456789abcdef/Messages.json": "/2010-04-01/Accounts/{id}/Messages.json",
+		"/files/AbCdEf1234567890xyz":                                            "/files/{id}",
+		"/files/abcdefghijklmnopqrstuvwxyz":                                     "/files/abcdefghijklmnopqrstuvwxyz",
+		"/":                                                                     "/",
+	}
+	for endpoint, want := range tests {
+		if got := endpointTemplate(endpoint); got != want {
+			t.Errorf("endpointTemplate(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// errorClassGuidance is what usually fixes each class of failure. The
+// classes are assigned by the ingestion service.
+var errorClassGuidance = map[string]string{
+	"auth":            "Check API keys, scopes and credential rotation; retrying will not help.",
+	"rate_limit":      "Back off and spread load, or request a higher limit or quota from the provider.",
+	"validation":      "Fix the request payloads; these calls fail the same way on every retry.",
+	"not_found":       "Look for stale or deleted IDs being referenced by callers.",
+	"timeout":         "Raise client timeouts or shrink requests, and retry with backoff.",
+	"provider_outage": "Check the provider's status page; retry with backoff or fail over.",
+	"client_bug":      "Inspect the failing call site; the provider rejected an unexpected request.",
+	"unclassified":    "Recorded before error classification was enabled.",
+}
+
+type ErrorClassBreakdown struct {
+	OrganizationID int         `json:"organization_id"`
+	Provider       string      `json:"provider"`
+	ErrorClass     string      `json:"error_class"`
+	Count          int         `json:"count"`
+	PreviousCount  int         `json:"previous_count"`
+	Share          float64     `json:"share"`
+	Cost           float64     `json:"cost"`
+	StatusCodes    map[int]int `json:"status_codes"`
+	TopEndpoint    string      `json:"top_endpoint"`
+	SampleMessage  string      `json:"sample_message,omitempty"`
+	Guidance       string      `json:"guidance"`
+}
+
+func (e ErrorClassBreakdown) Organization() int { return e.OrganizationID }
+
+// detectErrorClasses breaks the second half of the window's failures down
+// by provider and error class, with the first half for comparison.
+func (s *AnalyticsServer) detectErrorClasses(ctx context.Context, w Window) ([]ErrorClassBreakdown, error) {
+	query := `
+        SELECT
+            organization_id,
+            provider,
+            COALESCE(error_class, 'unclassified') as error_class,
+            endpoint,
+            COALESCE(status_code, 0),
+            COUNT(*) FILTER (WHERE time >= $3) as recent,
+            COUNT(*) FILTER (WHERE time < $3) as previous,
+            COALESCE(SUM(cost) FILTER (WHERE time >= $3), 0) as cost,
+            COALESCE(MAX(error_message) FILTER (WHERE time >= $3), '') as sample
+        FROM api_requests
+        WHERE
+            time >= $1 AND time < $2
+            AND (status_code >= 400 OR error_class IS NOT NULL)
+        GROUP BY organization_id, provider, error_class, endpoint, status_code
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To, w.To.Add(-w.Span()/2))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type classKey struct {
+		orgID    int
+		provider string
+		class    string
+	}
+	breakdowns := map[classKey]*ErrorClassBreakdown{}
+	endpointCounts := map[classKey]map[string]int{}
+	providerTotals := map[classKey]int{}
+
+	for rows.Next() {
+		var key classKey
+		var endpoint, sample string
+		var status, recent, previous int
+		var cost float64
+		if err := rows.Scan(&key.orgID, &key.provider, &key.class, &endpoint, &status, &recent, &previous, &cost, &sample); err != nil {
+			continue
+		}
+
+		b, ok := breakdowns[key]
+		if !ok {
+			b = &ErrorClassBreakdown{
+				OrganizationID: key.orgID,
+				Provider:       key.provider,
+				ErrorClass:     key.class,
+				StatusCodes:    map[int]int{},
+				Guidance:       errorClassGuidance[key.class],
+			}
+			breakdowns[key] = b
+			endpointCounts[key] = map[string]int{}
+		}
+		b.Count += recent
+		b.PreviousCount += previous
+		b.Cost += cost
+		if recent > 0 {
+			b.StatusCodes[status] += recent
+			endpointCounts[key][endpointTemplate(endpoint)] += recent
+			if b.SampleMessage == "" {
+				b.SampleMessage = sample
+			}
+		}
+		providerTotals[classKey{orgID: key.orgID, provider: key.provider}] += recent
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := []ErrorClassBreakdown{}
+	for key, b := range breakdowns {
+		if b.Count == 0 {
+			continue
+		}
+		if total := providerTotals[classKey{orgID: key.orgID, provider: key.provider}]; total > 0 {
+			b.Share = 100.0 * float64(b.Count) / float64(total)
+		}
+		best := 0
+		for endpoint, n := range endpointCounts[key] {
+			if n > best || (n == best && endpoint < b.TopEndpoint) {
+				best = n
+				b.TopEndpoint = endpoint
+			}
+		}
+		results = append(results, *b)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Provider < results[j].Provider
+	})
+
+	return results, nil
+}
@@ -3,43 +3,64 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"github.com/yourusername/api-observatory/shared/tenant"
 )
 
 type AnalyticsServer struct {
 	db    *sql.DB
 	redis *redis.Client
+	jobs  *jobs.Scheduler
+	orgs  *tenant.Publisher
 }
 
 type DuplicateGroup struct {
-	Endpoint  string    `json:"endpoint"`
-	Count     int       `json:"count"`
-	Cost      float64   `json:"cost"`
-	FirstSeen time.Time `json:"first_seen"`
-	LastSeen  time.Time `json:"last_seen"`
+	OrganizationID int       `json:"organization_id"`
+	Endpoint       string    `json:"endpoint"`
+	Count          int       `json:"count"`
+	Cost           float64   `json:"cost"`
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
 }
 
+func (d DuplicateGroup) Organization() int { return d.OrganizationID }
+
 type CacheRecommendation struct {
-	Endpoint         string  `json:"endpoint"`
-	CacheHitRatio    float64 `json:"cache_hit_ratio"`
-	PotentialSavings float64 `json:"potential_savings"`
-	Recommendation   string  `json:"recommendation"`
+	OrganizationID   int              `json:"organization_id"`
+	Endpoint         string           `json:"endpoint"`
+	CacheHitRatio    float64          `json:"cache_hit_ratio"`
+	PotentialSavings float64          `json:"potential_savings"`
+	Recommendation   string           `json:"recommendation"`
+	RecommendedSize  int              `json:"recommended_size"`
+	RecommendedTTL   int              `json:"recommended_ttl_seconds"`
+	LatencySavedMS   int64            `json:"latency_saved_ms"`
+	Simulations      []CacheSimResult `json:"simulations"`
 }
 
+func (c CacheRecommendation) Organization() int { return c.OrganizationID }
+
 type Anomaly struct {
-	Type        string    `json:"type"`
-	Severity    string    `json:"severity"`
-	Description string    `json:"description"`
-	DetectedAt  time.Time `json:"detected_at"`
+	OrganizationID int               `json:"organization_id"`
+	Provider       string            `json:"provider,omitempty"`
+	Type           string            `json:"type"`
+	Severity       string            `json:"severity"`
+	Description    string            `json:"description"`
+	DetectedAt     time.Time         `json:"detected_at"`
+	Observed       float64           `json:"observed,omitempty"`
+	Expected       float64           `json:"expected,omitempty"`
+	RootCause      []CostContributor `json:"root_cause,omitempty"`
 }
 
+func (a Anomaly) Organization() int { return a.OrganizationID }
+
 func main() {
 	dbURL := os.Getenv("DATABASE_URL")
 	db, err := sql.Open("postgres", dbURL)
@@ -57,32 +78,27 @@ func main() {
 	server := &AnalyticsServer{
 		db:    db,
 		redis: rdb,
+		jobs:  jobs.NewScheduler(rdb, "analytics"),
+		orgs:  tenant.NewPublisher(db, rdb),
+	}
+
+	registry := NewRegistry()
+	server.registerBuiltinDetectors(registry)
+
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		os.Exit(server.runBacktest(registry, os.Args[2:]))
 	}
 
 	// Start background analysis jobs
-	go server.continuousAnalysis()
+	server.runDetectors(registry)
+	go server.runStreamingAnalysis()
 
 	log.Println("Analytics-service running and analyzing usage patterns...")
 	select {} // block forever
 }
 
-func (s *AnalyticsServer) continuousAnalysis() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		log.Println("Running continuous analysis...")
-		s.detectDuplicates()
-		s.analyzeCacheOpportunities()
-		s.detectAnomalies()
-		<-ticker.C
-	}
-}
-
-func (s *AnalyticsServer) detectDuplicates() {
-	ctx := context.Background()
-
-	// Find duplicate requests within 1-hour window
+func (s *AnalyticsServer) detectDuplicates(ctx context.Context, w Window) ([]DuplicateGroup, error) {
+	// Find duplicate requests within the window
 	query := `
         WITH request_hashes AS (
             SELECT
@@ -93,7 +109,7 @@ func (s *AnalyticsServer) detectDuplicates() {
                 time,
                 cost
             FROM api_requests
-            WHERE time > NOW() - INTERVAL '1 hour'
+            WHERE time >= $1 AND time < $2
         ),
         duplicates AS (
             SELECT
@@ -103,7 +119,8 @@ func (s *AnalyticsServer) detectDuplicates() {
                 COUNT(*) as duplicate_count,
                 SUM(cost) as total_cost,
                 MIN(time) as first_seen,
-                MAX(time) as last_seen
+                MAX(time) as last_seen,
+                ROW_NUMBER() OVER (PARTITION BY organization_id ORDER BY SUM(cost) DESC) as rank
             FROM request_hashes
             GROUP BY organization_id, request_hash, endpoint
             HAVING COUNT(*) > 1
@@ -117,14 +134,13 @@ func (s *AnalyticsServer) detectDuplicates() {
             first_seen,
             last_seen
         FROM duplicates
+        WHERE rank <= 100
         ORDER BY total_cost DESC
-        LIMIT 100
     `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
 	if err != nil {
-		log.Printf("Failed to detect duplicates: %v", err)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -141,161 +157,237 @@ func (s *AnalyticsServer) detectDuplicates() {
 		}
 
 		duplicates = append(duplicates, DuplicateGroup{
-			Endpoint:  endpoint,
-			Count:     count,
-			Cost:      cost,
-			FirstSeen: firstSeen,
-			LastSeen:  lastSeen,
+			OrganizationID: orgID,
+			Endpoint:       endpoint,
+			Count:          count,
+			Cost:           cost,
+			FirstSeen:      firstSeen,
+			LastSeen:       lastSeen,
 		})
 	}
 
-	// Cache results in Redis
-	if len(duplicates) > 0 {
-		data, _ := json.Marshal(duplicates)
-		s.redis.Set(ctx, "analytics:duplicates", data, 10*time.Minute)
-		log.Printf("Detected %d duplicate patterns", len(duplicates))
-	}
+	return duplicates, rows.Err()
 }
 
-func (s *AnalyticsServer) analyzeCacheOpportunities() {
-	ctx := context.Background()
-
-	// Identify GET requests with high repeat rates
+func (s *AnalyticsServer) analyzeCacheOpportunities(ctx context.Context, w Window) ([]CacheRecommendation, error) {
+	// Candidate endpoints: GETs that repeat at least some of their requests.
+	// Each organization's traffic is simulated separately.
 	query := `
-        WITH endpoint_stats AS (
+        SELECT organization_id, endpoint, total_requests
+        FROM (
             SELECT
+                organization_id,
                 endpoint,
                 COUNT(*) as total_requests,
-                COUNT(DISTINCT MD5(endpoint || COALESCE(metadata::text, ''))) as unique_requests,
-                SUM(cost) as total_cost,
-                AVG(latency_ms) as avg_latency
+                ROW_NUMBER() OVER (PARTITION BY organization_id ORDER BY COUNT(*) DESC) as rank
             FROM api_requests
             WHERE
                 method = 'GET'
-                AND time > NOW() - INTERVAL '24 hours'
-                AND status_code < 400
-            GROUP BY endpoint
-            HAVING COUNT(*) > 10
-        )
-        SELECT
-            endpoint,
-            total_requests,
-            unique_requests,
-            total_cost,
-            avg_latency,
-            ROUND(100.0 * (total_requests - unique_requests) / total_requests, 2) as cache_hit_ratio
-        FROM endpoint_stats
-        WHERE unique_requests < total_requests
-        ORDER BY cache_hit_ratio DESC
-        LIMIT 50
+                AND time >= $1 AND time < $2
+            GROUP BY organization_id, endpoint
+            HAVING
+                COUNT(*) > 10
+                AND COUNT(DISTINCT ` + cacheKeyExpr + `) < COUNT(*)
+        ) candidates
+        WHERE rank <= 50
+        ORDER BY total_requests DESC
     `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
 	if err != nil {
-		log.Printf("Failed to analyze cache opportunities: %v", err)
-		return
+		return nil, err
 	}
-	defer rows.Close()
 
-	recommendations := []CacheRecommendation{}
+	type candidate struct {
+		orgID    int
+		endpoint string
+	}
+	candidates := []candidate{}
 	for rows.Next() {
-		var endpoint string
-		var totalReqs, uniqueReqs int
-		var totalCost, avgLatency, cacheRatio float64
+		var c candidate
+		var totalReqs int
+		if err := rows.Scan(&c.orgID, &c.endpoint, &totalReqs); err != nil {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recommendations := []CacheRecommendation{}
+	for _, c := range candidates {
+		endpoint := c.endpoint
+		calls, err := s.loadCacheableCalls(ctx, w, c.orgID, endpoint)
+		if err != nil {
+			log.Printf("Failed to load GET traffic for %s: %v", endpoint, err)
+			continue
+		}
 
-		if err := rows.Scan(&endpoint, &totalReqs, &uniqueReqs, &totalCost, &avgLatency, &cacheRatio); err != nil {
+		simulations := simulateCacheGrid(calls)
+		knee, ok := cacheKnee(simulations)
+		if !ok {
 			continue
 		}
 
-		potentialSavings := totalCost * (cacheRatio / 100.0) * 0.8
-		recommendation := fmt.Sprintf("Cache this endpoint with TTL of %d seconds. Could save %.2f%% of requests.",
-			int(avgLatency/1000)*10, cacheRatio)
+		ttl := time.Duration(knee.TTLSeconds) * time.Second
+		recommendation := fmt.Sprintf("Cache with an LRU of %d entries and a TTL of %s: %.1f%% of %d replayed requests hit, saving $%.4f and %s of latency per day.",
+			knee.Size, ttl, knee.HitRatio, knee.Requests, knee.CostSaved,
+			(time.Duration(knee.LatencySavedMS) * time.Millisecond).Round(time.Second))
 
 		recommendations = append(recommendations, CacheRecommendation{
+			OrganizationID:   c.orgID,
 			Endpoint:         endpoint,
-			CacheHitRatio:    cacheRatio,
-			PotentialSavings: potentialSavings,
+			CacheHitRatio:    knee.HitRatio,
+			PotentialSavings: knee.CostSaved,
 			Recommendation:   recommendation,
+			RecommendedSize:  knee.Size,
+			RecommendedTTL:   knee.TTLSeconds,
+			LatencySavedMS:   knee.LatencySavedMS,
+			Simulations:      simulations,
 		})
 	}
 
-	if len(recommendations) > 0 {
-		data, _ := json.Marshal(recommendations)
-		s.redis.Set(ctx, "analytics:cache_recommendations", data, 10*time.Minute)
-		log.Printf("Generated %d cache recommendations", len(recommendations))
-	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].PotentialSavings > recommendations[j].PotentialSavings
+	})
+
+	return recommendations, nil
 }
 
-func (s *AnalyticsServer) detectAnomalies() {
-	ctx := context.Background()
+// detectAnomalies scores every hour in the window against its seasonal
+// baseline. Parameters: history, sensitivity and scale_floor (override
+// anomaly_settings).
+func (s *AnalyticsServer) detectAnomalies(ctx context.Context, w Window) ([]Anomaly, error) {
+	historySpan, err := w.Params.duration("history", 28*24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+
+	// Baselines come from four weeks of the hourly aggregate, excluding the
+	// last 24 hours that are being scored.
+	history, err := s.loadCostHistory(ctx, w.To.Add(-historySpan), w.To.Add(-24*time.Hour).Truncate(time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("load cost baselines: %w", err)
+	}
+
+	settings, err := s.loadSpikeSettings(ctx)
+	if err != nil {
+		log.Printf("Failed to load anomaly settings, using defaults: %v", err)
+		settings = map[int]spikeSettings{}
+	}
+	orgSettings := func(orgID int) (spikeSettings, error) {
+		set, ok := settings[orgID]
+		if !ok {
+			set = defaultSpikeSettings()
+		}
+		var err error
+		if set.sensitivity, err = w.Params.float("sensitivity", set.sensitivity); err != nil {
+			return set, fmt.Errorf("sensitivity: %w", err)
+		}
+		if set.scaleFloor, err = w.Params.float("scale_floor", set.scaleFloor); err != nil {
+			return set, fmt.Errorf("scale_floor: %w", err)
+		}
+		return set, nil
+	}
 
-	// Detect cost spikes (spending >3x the average)
 	query := `
-        WITH hourly_costs AS (
-            SELECT
-                time_bucket('1 hour', time) as hour,
-                organization_id,
-                SUM(cost) as hourly_cost,
-                COUNT(*) as request_count
-            FROM api_requests
-            WHERE time > NOW() - INTERVAL '24 hours'
-            GROUP BY hour, organization_id
-        ),
-        avg_costs AS (
-            SELECT
-                organization_id,
-                AVG(hourly_cost) as avg_cost,
-                STDDEV(hourly_cost) as stddev_cost
-            FROM hourly_costs
-            GROUP BY organization_id
-        )
         SELECT
-            hc.organization_id,
-            hc.hour,
-            hc.hourly_cost,
-            ac.avg_cost,
-            hc.request_count
-        FROM hourly_costs hc
-        JOIN avg_costs ac ON hc.organization_id = ac.organization_id
-        WHERE hc.hourly_cost > (ac.avg_cost + 3 * ac.stddev_cost)
-        ORDER BY hc.hour DESC
+            time_bucket('1 hour', time) as hour,
+            organization_id,
+            SUM(cost) as hourly_cost,
+            COUNT(*) as request_count
+        FROM api_requests
+        WHERE time >= $1 AND time < $2
+        GROUP BY hour, organization_id
+        ORDER BY hour DESC
     `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
 	if err != nil {
-		log.Printf("Failed to detect anomalies: %v", err)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
+	baselines := map[int]*seasonalBaseline{}
 	anomalies := []Anomaly{}
 	for rows.Next() {
-		var orgID int
-		var hour time.Time
-		var hourlyCost, avgCost float64
-		var requestCount int
-
-		if err := rows.Scan(&orgID, &hour, &hourlyCost, &avgCost, &requestCount); err != nil {
+		var h hourlyCost
+		if err := rows.Scan(&h.hour, &h.orgID, &h.cost, &h.requests); err != nil {
 			continue
 		}
 
-		spike := ((hourlyCost - avgCost) / avgCost) * 100
-		description := fmt.Sprintf("Cost spike: $%.2f (%.0f%% above average). %d requests/hour.",
-			hourlyCost, spike, requestCount)
+		baseline, ok := baselines[h.orgID]
+		if !ok {
+			baseline = newSeasonalBaseline(history[h.orgID])
+			baselines[h.orgID] = baseline
+		}
 
-		anomaly := Anomaly{
-			Type:        "cost_spike",
-			Severity:    "high",
-			Description: description,
-			DetectedAt:  hour,
+		set, err := orgSettings(h.orgID)
+		if err != nil {
+			return nil, err
+		}
+
+		if anomaly, ok := scoreCostSpike(h, baseline, set); ok {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range anomalies {
+		a := &anomalies[i]
+		rootCause, err := s.attributeCostSpike(ctx, a.OrganizationID, a.DetectedAt, w.To, a.Observed, a.Expected)
+		if err != nil {
+			log.Printf("Failed to attribute cost spike for org %d at %s: %v", a.OrganizationID, a.DetectedAt, err)
+			continue
 		}
+		a.RootCause = rootCause
+		if len(rootCause) > 0 {
+			top := rootCause[0]
+			a.Description += fmt.Sprintf(" Top contributor: %s=%s (+$%.2f, %.0f%% of the increase).",
+				top.Dimension, top.Value, top.Delta, top.Share)
+		}
+	}
 
-		anomalies = append(anomalies, anomaly)
+	return anomalies, nil
+}
+
+// scoreCostSpike flags h when its cost is more than the org's sensitivity
+// in robust deviations above the seasonal baseline for its slot.
+func scoreCostSpike(h hourlyCost, baseline *seasonalBaseline, set spikeSettings) (Anomaly, bool) {
+	estimate, ok := baseline.estimate(h.hour, set.scaleFloor)
+	if !ok {
+		return Anomaly{}, false
 	}
 
-	if len(anomalies) > 0 {
-		data, _ := json.Marshal(anomalies)
-		s.redis.Set(ctx, "analytics:anomalies", data, 10*time.Minute)
-		log.Printf("Detected %d anomalies", len(anomalies))
+	z := estimate.score(h.cost)
+	if z <= set.sensitivity {
+		return Anomaly{}, false
 	}
+
+	severity := "medium"
+	if z >= 2*set.sensitivity {
+		severity = "high"
+	}
+
+	description := fmt.Sprintf("Cost spike: $%.2f vs $%.2f typical for %s (z=%.1f). %d requests/hour.",
+		h.cost, estimate.median, estimate.slot, z, h.requests)
+	if estimate.median > 0 {
+		spike := ((h.cost - estimate.median) / estimate.median) * 100
+		description = fmt.Sprintf("Cost spike: $%.2f (%.0f%% above the %s baseline, z=%.1f). %d requests/hour.",
+			h.cost, spike, estimate.slot, z, h.requests)
+	}
+
+	return Anomaly{
+		OrganizationID: h.orgID,
+		Type:           "cost_spike",
+		Severity:       severity,
+		Description:    description,
+		DetectedAt:     h.hour,
+		Observed:       h.cost,
+		Expected:       estimate.median,
+	}, true
 }
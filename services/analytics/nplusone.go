@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+const (
+	// Per-item calls no further apart than this belong to the same fan-out.
+	defaultFanoutGap = 2 * time.Second
+	// Fewer per-item calls than this is not worth reporting.
+	minFanout = 5
+)
+
+type traceCall struct {
+	provider string
+	method   string
+	template string
+	callSite string
+	at       time.Time
+	cost     float64
+}
+
+type NPlusOnePattern struct {
+	OrganizationID    int     `json:"organization_id"`
+	Provider          string  `json:"provider"`
+	ParentCall        string  `json:"parent_call,omitempty"`
+	ItemCall          string  `json:"item_call"`
+	CallSite          string  `json:"call_site"`
+	Occurrences       int     `json:"occurrences"`
+	AvgFanout         float64 `json:"avg_fanout"`
+	MaxFanout         int     `json:"max_fanout"`
+	CostPerOccurrence float64 `json:"cost_per_occurrence"`
+	TotalCost         float64 `json:"total_cost"`
+	ExampleTrace      string  `json:"example_trace"`
+}
+
+func (n NPlusOnePattern) Organization() int { return n.OrganizationID }
+
+type fanout struct {
+	parent *traceCall
+	items  []traceCall
+}
+
+func isItemCall(c traceCall) bool {
+	return c.method == "GET" && strings.HasSuffix(c.template, "/{id}")
+}
+
+// findFanouts scans one trace for runs of at least minFanout per-item GETs to
+// the same template, uninterrupted by any other call, each attributed to the call just before the run when
+// that call went to the same provider and is not itself a per-item call, as
+// the list call in an N+1 usually is.
+func findFanouts(calls []traceCall, gap time.Duration) []fanout {
+	found := []fanout{}
+
+	for i := 0; i < len(calls); {
+		if !isItemCall(calls[i]) {
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(calls) && isItemCall(calls[j]) && calls[j].method == calls[i].method &&
+			calls[j].template == calls[i].template && calls[j].provider == calls[i].provider &&
+			calls[j].at.Sub(calls[j-1].at) <= gap {
+			j++
+		}
+
+		if j-i >= minFanout {
+			f := fanout{items: calls[i:j]}
+			if i > 0 && !isItemCall(calls[i-1]) && calls[i-1].provider == calls[i].provider &&
+				calls[i].at.Sub(calls[i-1].at) <= gap {
+				f.parent = &calls[i-1]
+			}
+			found = append(found, f)
+		}
+		i = j
+	}
+
+	return found
+}
+
+// detectNPlusOne finds traces that fan out into one call per item.
+// Parameters: gap.
+func (s *AnalyticsServer) detectNPlusOne(ctx context.Context, w Window) ([]NPlusOnePattern, error) {
+	gap, err := w.Params.duration("gap", jobs.EnvDuration("NPLUS1_GAP", defaultFanoutGap))
+	if err != nil {
+		return nil, fmt.Errorf("gap: %w", err)
+	}
+
+	query := `
+        SELECT
+            organization_id,
+            COALESCE(metadata->>'trace_id', metadata->>'session_id') as trace_id,
+            COALESCE(metadata->>'call_site', ` + callerExpr("") + `) as call_site,
+            provider,
+            method,
+            endpoint,
+            time,
+            COALESCE(cost, 0)
+        FROM api_requests
+        WHERE
+            time >= $1 AND time < $2
+            AND COALESCE(metadata->>'trace_id', metadata->>'session_id') IS NOT NULL
+        ORDER BY organization_id, trace_id, time
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type traceKey struct {
+		orgID   int
+		traceID string
+	}
+	traces := map[traceKey][]traceCall{}
+	order := []traceKey{}
+
+	for rows.Next() {
+		var key traceKey
+		var call traceCall
+		var endpoint string
+
+		if err := rows.Scan(&key.orgID, &key.traceID, &call.callSite, &call.provider, &call.method, &endpoint, &call.at, &call.cost); err != nil {
+			continue
+		}
+		call.template = endpointTemplate(endpoint)
+
+		if _, ok := traces[key]; !ok {
+			order = append(order, key)
+		}
+		traces[key] = append(traces[key], call)
+	}
+
+	type patternKey struct {
+		orgID                  int
+		provider, parent, item string
+		callSite               string
+	}
+	patterns := map[patternKey]*NPlusOnePattern{}
+
+	for _, tk := range order {
+		for _, f := range findFanouts(traces[tk], gap) {
+			first := f.items[0]
+			pk := patternKey{
+				orgID:    tk.orgID,
+				provider: first.provider,
+				item:     first.method + " " + first.template,
+				callSite: first.callSite,
+			}
+
+			cost := 0.0
+			for _, item := range f.items {
+				cost += item.cost
+			}
+			if f.parent != nil {
+				pk.parent = f.parent.method + " " + f.parent.template
+				cost += f.parent.cost
+			}
+
+			p, ok := patterns[pk]
+			if !ok {
+				p = &NPlusOnePattern{
+					OrganizationID: tk.orgID,
+					Provider:       pk.provider,
+					ParentCall:     pk.parent,
+					ItemCall:       pk.item,
+					CallSite:       pk.callSite,
+					ExampleTrace:   tk.traceID,
+				}
+				patterns[pk] = p
+			}
+
+			p.Occurrences++
+			p.AvgFanout += float64(len(f.items))
+			p.TotalCost += cost
+			if len(f.items) > p.MaxFanout {
+				p.MaxFanout = len(f.items)
+			}
+		}
+	}
+
+	results := []NPlusOnePattern{}
+	for _, p := range patterns {
+		p.AvgFanout /= float64(p.Occurrences)
+		p.CostPerOccurrence = p.TotalCost / float64(p.Occurrences)
+		results = append(results, *p)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].TotalCost > results[j].TotalCost
+	})
+
+	return results, rows.Err()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindFanouts(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	call := func(ms int, provider, template string) traceCall {
+		return traceCall{provider: provider, method: "GET", template: template, at: start.Add(time.Duration(ms) * time.Millisecond)}
+	}
+	// run appends n per-item calls to calls, step ms apart starting at from.
+	run := func(calls []traceCall, n, from, step int, template string) []traceCall {
+		for i := 0; i < n; i++ {
+			calls = append(calls, call(from+i*step, "Stripe", template))
+		}
+		return calls
+	}
+	withMethod := func(c traceCall, method string) traceCall {
+		c.method = method
+		return c
+	}
+	list := []traceCall{call(0, "Stripe", "/v1/customers")}
+	const customer, charge = "/v1/customers/{id}", "/v1/charges/{id}"
+
+	tests := []struct {
+		name    string
+		calls   []traceCall
+		items   []int  // size of each fan-out found
+		parents []bool // whether each fan-out has a parent call
+	}{
+		{name: "empty"},
+		{name: "list then items", calls: run(list, 5, 100, 100, customer), items: []int{5}, parents: []bool{true}},
+		{name: "too few items", calls: run(list, 4, 100, 100, customer)},
+		{name: "no parent call", calls: run(nil, 6, 0, 100, customer), items: []int{6}, parents: []bool{false}},
+		{
+			name:    "parent on another provider",
+			calls:   run([]traceCall{call(0, "OpenAI", "/v1/models")}, 5, 100, 100, customer),
+			items:   []int{5},
+			parents: []bool{false},
+		},
+		{name: "parent too long before", calls: run(list, 5, 5000, 100, customer), items: []int{5}, parents: []bool{false}},
+		{name: "gap splits the run", calls: run(run(nil, 3, 0, 100, customer), 3, 5000, 100, customer)},
+		{
+			name:    "template change splits the run",
+			calls:   run(run(nil, 5, 0, 100, customer), 5, 500, 100, charge),
+			items:   []int{5, 5},
+			parents: []bool{false, false},
+		},
+		{name: "non-item calls are skipped", calls: run(nil, 5, 0, 100, "/v1/customers")},
+		{
+			name:  "a write to the same template splits the run",
+			calls: run(append(run(nil, 3, 0, 100, customer), withMethod(call(300, "Stripe", customer), "DELETE")), 3, 400, 100, customer),
+		},
+		{
+			name:    "a write ends the run",
+			calls:   append(run(nil, 5, 0, 100, customer), withMethod(call(500, "Stripe", customer), "POST")),
+			items:   []int{5},
+			parents: []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		got := findFanouts(tt.calls, 2*time.Second)
+		if len(got) != len(tt.items) {
+			t.Errorf("%s: findFanouts() returned %d fan-outs, want %d", tt.name, len(got), len(tt.items))
+			continue
+		}
+		for i, f := range got {
+			if len(f.items) != tt.items[i] || (f.parent != nil) != tt.parents[i] {
+				t.Errorf("%s: fan-out %d has %d items and parent %v, want %d and %v",
+					tt.name, i, len(f.items), f.parent != nil, tt.items[i], tt.parents[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/api-observatory/shared/billing"
+)
+
+const (
+	// Endpoints with fewer calls than this are not judged.
+	minPayloadSamples = 20
+	// An endpoint is large when its mean response is this many times the
+	// median of its provider's other endpoints.
+	payloadPeerMultiplier = 3.0
+	// Coefficient of variation above which response sizes are erratic.
+	payloadVariationLimit = 1.5
+	// Responses smaller than this aren't worth optimizing.
+	minPayloadBytes = 10 * 1024
+	// Typical gzip ratio for JSON and text.
+	compressedFraction = 0.3
+)
+
+type PayloadRecommendation struct {
+	OrganizationID      int     `json:"organization_id"`
+	Provider            string  `json:"provider"`
+	Method              string  `json:"method"`
+	Endpoint            string  `json:"endpoint"`
+	Requests            int     `json:"requests"`
+	AvgResponseBytes    float64 `json:"avg_response_bytes"`
+	MedianResponseBytes float64 `json:"median_response_bytes"`
+	MaxResponseBytes    int64   `json:"max_response_bytes"`
+	Variation           float64 `json:"variation"`
+	PeerMedianBytes     float64 `json:"peer_median_bytes,omitempty"`
+	Reason              string  `json:"reason"` // large_vs_peers or high_variance
+	Technique           string  `json:"technique"`
+	Recommendation      string  `json:"recommendation"`
+	BandwidthSavedBytes int64   `json:"bandwidth_saved_bytes"` // per day
+	CostSaved           float64 `json:"cost_saved"`            // per day
+}
+
+func (p PayloadRecommendation) Organization() int { return p.OrganizationID }
+
+// payloadStats accumulates response sizes for one endpoint template. The
+// median is the request-weighted mean of per-endpoint medians, which is
+// close enough for sizing a recommendation.
+type payloadStats struct {
+	orgID     int
+	provider  string
+	method    string
+	template  string
+	count     int
+	sum       float64
+	sumSq     float64
+	medianSum float64
+	max       int64
+}
+
+func (p *payloadStats) mean() float64   { return p.sum / float64(p.count) }
+func (p *payloadStats) median() float64 { return p.medianSum / float64(p.count) }
+
+func (p *payloadStats) variation() float64 {
+	mean := p.mean()
+	if mean == 0 {
+		return 0
+	}
+	variance := p.sumSq/float64(p.count) - mean*mean
+	return math.Sqrt(math.Max(variance, 0)) / mean
+}
+
+// payloadTechnique picks the fix most likely to shrink responses for this
+// kind of endpoint, and the mean size it would bring them down to.
+func payloadTechnique(p *payloadStats, target float64) (string, string, float64) {
+	endpoint := strings.ToLower(p.template)
+
+	switch {
+	case p.provider == "OpenAI" && (strings.Contains(endpoint, "completions") || strings.Contains(endpoint, "responses")):
+		return "max_tokens", "Set max_tokens and ask for concise output; completion tokens are billed by size", target
+	case p.provider == "AWS S3" && p.method == "GET":
+		return "range_requests", "Use ranged GETs or store objects compressed so clients fetch only the bytes they need", target
+	case p.method == "GET" && !strings.HasSuffix(endpoint, "/{id}"):
+		return "pagination", "Paginate with a smaller limit instead of fetching whole collections", target
+	case p.method == "GET":
+		return "field_selection", "Request only the fields you use (fields/select parameters, or avoid expanding nested objects)", target
+	default:
+		return "compression", "Enable gzip compression (Accept-Encoding: gzip) for these responses", p.mean() * compressedFraction
+	}
+}
+
+// detectPayloadOptimizations finds endpoints whose responses are large for
+// their provider or vary wildly in size, and estimates the bandwidth and
+// size-based cost a fix would save per day.
+func (s *AnalyticsServer) detectPayloadOptimizations(ctx context.Context, w Window) ([]PayloadRecommendation, error) {
+	query := `
+        SELECT
+            organization_id,
+            provider,
+            method,
+            endpoint,
+            COUNT(*) as requests,
+            SUM(response_size_bytes)::float8,
+            SUM(response_size_bytes::float8 * response_size_bytes),
+            percentile_cont(0.5) WITHIN GROUP (ORDER BY response_size_bytes),
+            MAX(response_size_bytes)
+        FROM api_requests
+        WHERE
+            time >= $1 AND time < $2
+            AND response_size_bytes IS NOT NULL
+        GROUP BY organization_id, provider, method, endpoint
+    `
+
+	days := w.To.Sub(w.From).Hours() / 24
+	if days <= 0 {
+		return []PayloadRecommendation{}, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type statsKey struct {
+		orgID    int
+		provider string
+		method   string
+		template string
+	}
+	stats := map[statsKey]*payloadStats{}
+
+	for rows.Next() {
+		var orgID, count int
+		var provider, method, endpoint string
+		var sum, sumSq, median float64
+		var max int64
+		if err := rows.Scan(&orgID, &provider, &method, &endpoint, &count, &sum, &sumSq, &median, &max); err != nil {
+			continue
+		}
+
+		key := statsKey{orgID, provider, method, endpointTemplate(endpoint)}
+		p, ok := stats[key]
+		if !ok {
+			p = &payloadStats{orgID: orgID, provider: provider, method: method, template: key.template}
+			stats[key] = p
+		}
+		p.count += count
+		p.sum += sum
+		p.sumSq += sumSq
+		p.medianSum += median * float64(count)
+		if max > p.max {
+			p.max = max
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]*payloadStats, 0, len(stats))
+	for _, p := range stats {
+		list = append(list, p)
+	}
+	return judgePayloads(list, days), nil
+}
+
+// judgePayloads compares each endpoint with its peers, the other endpoints of
+// the same organization and provider, and recommends a fix for those whose
+// responses are large or erratic. Savings are per day of a window of days.
+func judgePayloads(stats []*payloadStats, days float64) []PayloadRecommendation {
+	type peerKey struct {
+		orgID    int
+		provider string
+	}
+	peers := map[peerKey][]*payloadStats{}
+	for _, p := range stats {
+		if p.count >= minPayloadSamples {
+			k := peerKey{p.orgID, p.provider}
+			peers[k] = append(peers[k], p)
+		}
+	}
+
+	recommendations := []PayloadRecommendation{}
+	for _, group := range peers {
+		for _, p := range group {
+			mean := p.mean()
+			if mean < minPayloadBytes {
+				continue
+			}
+
+			peerMeans := []float64{}
+			for _, other := range group {
+				if other != p {
+					peerMeans = append(peerMeans, other.mean())
+				}
+			}
+			peerMedian := 0.0
+			if len(peerMeans) >= 2 {
+				peerMedian = medianOf(peerMeans)
+			}
+
+			var reason string
+			var target float64
+			switch {
+			case peerMedian > 0 && mean >= payloadPeerMultiplier*peerMedian:
+				reason, target = "large_vs_peers", peerMedian
+			case p.variation() >= payloadVariationLimit:
+				reason, target = "high_variance", p.median()
+			default:
+				continue
+			}
+
+			technique, advice, target := payloadTechnique(p, target)
+			if target >= mean {
+				continue
+			}
+			saved := (mean - target) * float64(p.count) / days
+
+			detail := fmt.Sprintf("averages %s per response, %.1fx the %s median of %s for other %s endpoints",
+				formatBytes(mean), mean/peerMedian, p.provider, formatBytes(peerMedian), p.provider)
+			if reason == "high_variance" {
+				detail = fmt.Sprintf("responses range up to %s against a median of %s", formatBytes(float64(p.max)), formatBytes(p.median()))
+			}
+
+			recommendations = append(recommendations, PayloadRecommendation{
+				OrganizationID:      p.orgID,
+				Provider:            p.provider,
+				Method:              p.method,
+				Endpoint:            p.template,
+				Requests:            p.count,
+				AvgResponseBytes:    mean,
+				MedianResponseBytes: p.median(),
+				MaxResponseBytes:    p.max,
+				Variation:           p.variation(),
+				PeerMedianBytes:     peerMedian,
+				Reason:              reason,
+				Technique:           technique,
+				Recommendation: fmt.Sprintf("%s %s %s. %s to save about %s a day.",
+					p.method, p.template, detail, advice, formatBytes(saved)),
+				BandwidthSavedBytes: int64(saved),
+				CostSaved:           saved / 1024 * billing.SizeCostPerKB,
+			})
+		}
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].BandwidthSavedBytes > recommendations[j].BandwidthSavedBytes
+	})
+
+	return recommendations
+}
+
+func formatBytes(b float64) string {
+	switch {
+	case b >= 1<<30:
+		return fmt.Sprintf("%.1f GB", b/(1<<30))
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MB", b/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KB", b/(1<<10))
+	default:
+		return fmt.Sprintf("%.0f B", b)
+	}
+}
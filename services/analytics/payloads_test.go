@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yourusername/api-observatory/shared/billing"
+)
+
+func TestJudgePayloads(t *testing.T) {
+	const kb = 1024.0
+	// endpoint builds the stats of count responses with the given mean,
+	// standard deviation and median sizes in KB.
+	endpoint := func(orgID int, provider, method, template string, count int, mean, sd, median float64) *payloadStats {
+		mean, sd, median = mean*kb, sd*kb, median*kb
+		return &payloadStats{
+			orgID:     orgID,
+			provider:  provider,
+			method:    method,
+			template:  template,
+			count:     count,
+			sum:       mean * float64(count),
+			sumSq:     (sd*sd + mean*mean) * float64(count),
+			medianSum: median * float64(count),
+			max:       int64(mean + 3*sd),
+		}
+	}
+	stripe := func(template string, mean float64) *payloadStats {
+		return endpoint(1, "Stripe", "GET", template, 100, mean, 0, mean)
+	}
+
+	type expectation struct {
+		reason     string
+		technique  string
+		peerMedian float64 // KB
+		savedKB    float64 // per day
+	}
+
+	tests := []struct {
+		name     string
+		stats    []*payloadStats
+		expected map[string]expectation // by endpoint
+	}{
+		{
+			name:  "large against the peer median",
+			stats: []*payloadStats{stripe("/v1/charges", 100), stripe("/v1/customers/{id}", 20), stripe("/v1/invoices/{id}", 25)},
+			expected: map[string]expectation{
+				"/v1/charges": {"large_vs_peers", "pagination", 22.5, (100 - 22.5) * 100 / 2},
+			},
+		},
+		{
+			name:  "just under the peer multiplier",
+			stats: []*payloadStats{stripe("/v1/charges", 59), stripe("/v1/customers/{id}", 20), stripe("/v1/invoices/{id}", 20)},
+		},
+		{
+			name:  "a single peer is not a median",
+			stats: []*payloadStats{stripe("/v1/charges", 100), stripe("/v1/customers/{id}", 20)},
+		},
+		{
+			name: "peers need enough samples",
+			stats: []*payloadStats{
+				stripe("/v1/charges", 100),
+				stripe("/v1/customers/{id}", 20),
+				endpoint(1, "Stripe", "GET", "/v1/invoices/{id}", minPayloadSamples-1, 25, 0, 25),
+			},
+		},
+		{
+			name: "peers are per organization and provider",
+			stats: []*payloadStats{
+				stripe("/v1/charges", 100),
+				endpoint(2, "Stripe", "GET", "/v1/customers/{id}", 100, 20, 0, 20),
+				endpoint(1, "Twilio", "GET", "/v1/messages/{id}", 100, 20, 0, 20),
+			},
+		},
+		{
+			name:  "erratic per-item responses",
+			stats: []*payloadStats{endpoint(1, "Stripe", "GET", "/v1/customers/{id}", 100, 40, 80, 10)},
+			expected: map[string]expectation{
+				"/v1/customers/{id}": {"high_variance", "field_selection", 0, (40 - 10) * 100 / 2},
+			},
+		},
+		{
+			name:  "steady responses",
+			stats: []*payloadStats{endpoint(1, "Stripe", "GET", "/v1/customers/{id}", 100, 40, 50, 10)},
+		},
+		{
+			name:  "writes are compressed",
+			stats: []*payloadStats{endpoint(1, "Stripe", "POST", "/v1/charges", 100, 40, 80, 10)},
+			expected: map[string]expectation{
+				"/v1/charges": {"high_variance", "compression", 0, 40 * (1 - compressedFraction) * 100 / 2},
+			},
+		},
+		{
+			name:  "completions cap tokens",
+			stats: []*payloadStats{endpoint(1, "OpenAI", "POST", "/v1/chat/completions", 100, 40, 80, 10)},
+			expected: map[string]expectation{
+				"/v1/chat/completions": {"high_variance", "max_tokens", 0, (40 - 10) * 100 / 2},
+			},
+		},
+		{
+			name:  "object downloads use ranges",
+			stats: []*payloadStats{endpoint(1, "AWS S3", "GET", "/bucket/{id}", 100, 40, 80, 10)},
+			expected: map[string]expectation{
+				"/bucket/{id}": {"high_variance", "range_requests", 0, (40 - 10) * 100 / 2},
+			},
+		},
+		{
+			name:  "no saving when the target is not below the mean",
+			stats: []*payloadStats{endpoint(1, "Stripe", "GET", "/v1/customers/{id}", 100, 40, 80, 45)},
+		},
+		{
+			name:  "small responses",
+			stats: []*payloadStats{endpoint(1, "Stripe", "GET", "/v1/customers/{id}", 100, 8, 16, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := judgePayloads(tt.stats, 2)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d recommendations, want %d: %+v", len(got), len(tt.expected), got)
+			}
+			for _, r := range got {
+				want, ok := tt.expected[r.Endpoint]
+				if !ok {
+					t.Fatalf("unexpected recommendation for %s: %s", r.Endpoint, r.Recommendation)
+				}
+				if r.Reason != want.reason || r.Technique != want.technique {
+					t.Errorf("%s: %s/%s, want %s/%s", r.Endpoint, r.Reason, r.Technique, want.reason, want.technique)
+				}
+				if math.Abs(r.PeerMedianBytes-want.peerMedian*kb) > 1e-6 {
+					t.Errorf("%s: PeerMedianBytes = %v, want %v", r.Endpoint, r.PeerMedianBytes, want.peerMedian*kb)
+				}
+				if saved := want.savedKB * kb; r.BandwidthSavedBytes != int64(saved) {
+					t.Errorf("%s: BandwidthSavedBytes = %d, want %d", r.Endpoint, r.BandwidthSavedBytes, int64(saved))
+				}
+				if cost := float64(r.BandwidthSavedBytes) / kb * billing.SizeCostPerKB; math.Abs(r.CostSaved-cost) > 1e-6 {
+					t.Errorf("%s: CostSaved = %v, want %v", r.Endpoint, r.CostSaved, cost)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// Utilization levels (percent of rate_limit_per_minute) that trigger warnings.
+const (
+	rateLimitWarnLevel     = 80.0
+	rateLimitCriticalLevel = 100.0
+	// A minute with at least this many 429s counts as a throttle burst.
+	throttleBurstMin = 3
+)
+
+type RateLimitStatus struct {
+	OrganizationID      int       `json:"organization_id"`
+	Provider            string    `json:"provider"`
+	RateLimitPerMinute  int       `json:"rate_limit_per_minute"`
+	PeakPerMinute       int       `json:"peak_per_minute"`
+	PeakAt              time.Time `json:"peak_at"`
+	CurrentPerMinute    float64   `json:"current_per_minute"`
+	Utilization         float64   `json:"utilization"`
+	TrendPerMinute      float64   `json:"trend_per_minute"`
+	MinutesToWarn       *float64  `json:"minutes_to_80_percent,omitempty"`
+	MinutesToLimit      *float64  `json:"minutes_to_100_percent,omitempty"`
+	ThrottledRequests   int       `json:"throttled_requests"`
+	ThrottleBursts      int       `json:"throttle_bursts"`
+	UtilizationAt429    float64   `json:"utilization_at_429,omitempty"`
+	Severity            string    `json:"severity,omitempty"`
+	Warning             string    `json:"warning,omitempty"`
+	ThrottleCorrelation string    `json:"throttle_correlation,omitempty"`
+}
+
+func (r RateLimitStatus) Organization() int { return r.OrganizationID }
+
+type minuteSample struct {
+	minute    time.Time
+	requests  int
+	throttled int
+}
+
+// monitorRateLimits projects each provider's per-minute rate against its
+// limit. Parameters: horizon.
+func (s *AnalyticsServer) monitorRateLimits(ctx context.Context, w Window) ([]RateLimitStatus, error) {
+	horizon, err := w.Params.duration("horizon", jobs.EnvDuration("RATE_LIMIT_HORIZON", 30*time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("horizon: %w", err)
+	}
+
+	// Per-minute request and 429 counts for the window, excluding the
+	// minute still in progress so the trend isn't dragged down by it.
+	end := w.To.Truncate(time.Minute)
+	start := end.Add(-w.Span())
+	query := `
+        SELECT
+            r.organization_id,
+            r.provider,
+            p.rate_limit_per_minute,
+            time_bucket('1 minute', r.time) as minute,
+            COUNT(*) as request_count,
+            COUNT(CASE WHEN r.status_code = 429 THEN 1 END) as throttled_count
+        FROM api_requests r
+        JOIN api_providers p ON p.name = r.provider
+        WHERE
+            r.time >= $1 AND r.time < $2
+            AND p.rate_limit_per_minute > 0
+        GROUP BY r.organization_id, r.provider, p.rate_limit_per_minute, minute
+        ORDER BY r.organization_id, r.provider, minute
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type seriesKey struct {
+		orgID    int
+		provider string
+	}
+	limits := map[seriesKey]int{}
+	series := map[seriesKey][]minuteSample{}
+	order := []seriesKey{}
+
+	for rows.Next() {
+		var key seriesKey
+		var limit int
+		var sample minuteSample
+
+		if err := rows.Scan(&key.orgID, &key.provider, &limit, &sample.minute, &sample.requests, &sample.throttled); err != nil {
+			continue
+		}
+
+		if _, ok := series[key]; !ok {
+			order = append(order, key)
+		}
+		limits[key] = limit
+		series[key] = append(series[key], sample)
+	}
+
+	statuses := []RateLimitStatus{}
+	for _, key := range order {
+		status := evaluateRateLimit(key.orgID, key.provider, limits[key], fillMinutes(series[key], start, end), horizon)
+		if status.Warning != "" {
+			log.Printf("Rate limit warning for org %d: %s", key.orgID, status.Warning)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// fillMinutes returns one sample per minute in [start, end), inserting zero
+// samples for minutes with no traffic so the trend reflects idle periods.
+func fillMinutes(samples []minuteSample, start, end time.Time) []minuteSample {
+	byMinute := make(map[int64]minuteSample, len(samples))
+	for _, s := range samples {
+		byMinute[s.minute.Unix()] = s
+	}
+
+	filled := []minuteSample{}
+	for m := start; m.Before(end); m = m.Add(time.Minute) {
+		sample, ok := byMinute[m.Unix()]
+		if !ok {
+			sample = minuteSample{minute: m}
+		}
+		filled = append(filled, sample)
+	}
+	return filled
+}
+
+func evaluateRateLimit(orgID int, provider string, limit int, samples []minuteSample, horizon time.Duration) RateLimitStatus {
+	status := RateLimitStatus{
+		OrganizationID:     orgID,
+		Provider:           provider,
+		RateLimitPerMinute: limit,
+	}
+
+	values := make([]float64, len(samples))
+	lowestAt429 := -1.0
+	for i, sample := range samples {
+		values[i] = float64(sample.requests)
+
+		if sample.requests > status.PeakPerMinute {
+			status.PeakPerMinute = sample.requests
+			status.PeakAt = sample.minute
+		}
+
+		status.ThrottledRequests += sample.throttled
+		if sample.throttled >= throttleBurstMin {
+			status.ThrottleBursts++
+			utilization := 100.0 * float64(sample.requests) / float64(limit)
+			if lowestAt429 < 0 || utilization < lowestAt429 {
+				lowestAt429 = utilization
+			}
+		}
+	}
+
+	slope, intercept := linearTrend(values)
+	current := intercept + slope*float64(len(values)-1)
+	if current < 0 {
+		current = 0
+	}
+
+	status.CurrentPerMinute = current
+	status.TrendPerMinute = slope
+	status.Utilization = 100.0 * float64(status.PeakPerMinute) / float64(limit)
+	status.MinutesToWarn = minutesUntil(current, slope, float64(limit)*rateLimitWarnLevel/100.0)
+	status.MinutesToLimit = minutesUntil(current, slope, float64(limit)*rateLimitCriticalLevel/100.0)
+
+	within := func(m *float64) bool {
+		return m != nil && *m <= horizon.Minutes()
+	}
+
+	switch {
+	case status.Utilization >= rateLimitCriticalLevel:
+		status.Severity = "high"
+		status.Warning = fmt.Sprintf("%s peaked at %d req/min, %.0f%% of the %d req/min limit.",
+			provider, status.PeakPerMinute, status.Utilization, limit)
+	case within(status.MinutesToLimit):
+		status.Severity = "high"
+		status.Warning = fmt.Sprintf("%s is projected to reach its %d req/min limit in %.0f minutes.",
+			provider, limit, *status.MinutesToLimit)
+	case status.Utilization >= rateLimitWarnLevel:
+		status.Severity = "medium"
+		status.Warning = fmt.Sprintf("%s peaked at %.0f%% of its %d req/min limit.",
+			provider, status.Utilization, limit)
+	case within(status.MinutesToWarn):
+		status.Severity = "medium"
+		status.Warning = fmt.Sprintf("%s is projected to reach 80%% of its %d req/min limit in %.0f minutes.",
+			provider, limit, *status.MinutesToWarn)
+	}
+
+	if status.ThrottleBursts > 0 {
+		status.UtilizationAt429 = lowestAt429
+		if lowestAt429 < rateLimitWarnLevel {
+			status.ThrottleCorrelation = fmt.Sprintf("%d 429 bursts at as low as %.0f%% utilization; the effective limit is likely below the configured %d req/min.",
+				status.ThrottleBursts, lowestAt429, limit)
+			if status.Severity == "" {
+				status.Severity = "medium"
+			}
+		} else {
+			status.ThrottleCorrelation = fmt.Sprintf("%d 429 bursts coincide with usage above %.0f%% of the limit.",
+				status.ThrottleBursts, rateLimitWarnLevel)
+		}
+	}
+
+	return status
+}
+
+// minutesUntil returns how many minutes a linear trend needs to go from
+// current to target, 0 if it is already there, or nil if it never will.
+func minutesUntil(current, slope, target float64) *float64 {
+	var minutes float64
+	switch {
+	case current >= target:
+		minutes = 0
+	case slope > 0:
+		minutes = (target - current) / slope
+	default:
+		return nil
+	}
+	return &minutes
+}
+
+// linearTrend fits y = intercept + slope*x by least squares, with x being the
+// sample index.
+func linearTrend(values []float64) (slope, intercept float64) {
+	n := float64(len(values))
+	if n == 0 {
+		return 0, 0
+	}
+	if n == 1 {
+		return 0, values[0]
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, sumY / n
+	}
+	slope = (n*sumXY - sumX*sumY) / denom
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLinearTrend(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []float64
+		slope     float64
+		intercept float64
+	}{
+		{"empty", nil, 0, 0},
+		{"single value", []float64{7}, 0, 7},
+		{"flat", []float64{5, 5, 5, 5}, 0, 5},
+		{"rising line", []float64{10, 12, 14, 16}, 2, 10},
+		{"falling line", []float64{9, 6, 3}, -3, 9},
+		{"noisy", []float64{1, 3, 2, 4}, 0.8, 1.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slope, intercept := linearTrend(tt.values)
+			if math.Abs(slope-tt.slope) > 1e-9 || math.Abs(intercept-tt.intercept) > 1e-9 {
+				t.Errorf("linearTrend() = %v, %v, want %v, %v", slope, intercept, tt.slope, tt.intercept)
+			}
+		})
+	}
+}
+
+func TestMinutesUntil(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  float64
+		slope    float64
+		target   float64
+		expected *float64
+	}{
+		{"rising", 40, 2, 80, floatPtr(20)},
+		{"already there", 90, 1, 80, floatPtr(0)},
+		{"over the target while falling", 90, -5, 80, floatPtr(0)},
+		{"zero slope", 40, 0, 80, nil},
+		{"falling", 40, -1, 80, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := minutesUntil(tt.current, tt.slope, tt.target)
+			switch {
+			case got == nil && tt.expected == nil:
+			case got == nil || tt.expected == nil:
+				t.Errorf("minutesUntil() = %v, want %v", got, tt.expected)
+			case math.Abs(*got-*tt.expected) > 1e-9:
+				t.Errorf("minutesUntil() = %v, want %v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestEvaluateRateLimit(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := func(requests []int, throttled map[int]int) []minuteSample {
+		out := make([]minuteSample, len(requests))
+		for i, n := range requests {
+			out[i] = minuteSample{minute: start.Add(time.Duration(i) * time.Minute), requests: n, throttled: throttled[i]}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name          string
+		requests      []int
+		throttled     map[int]int
+		severity      string
+		peak          int
+		warnIn        *float64 // checked when set
+		limitIn       *float64 // checked when set
+		bursts        int
+		correlated    bool // 429s at low utilization
+		utilizationAt float64
+	}{
+		{
+			name:     "quiet and flat",
+			requests: []int{10, 10, 10, 10},
+			peak:     10,
+		},
+		{
+			name:     "over the limit",
+			requests: []int{50, 120, 60},
+			severity: "high",
+			peak:     120,
+			warnIn:   floatPtr(0),
+		},
+		{
+			name:     "projected to hit the limit within the horizon",
+			requests: []int{40, 50, 60, 70},
+			severity: "high",
+			peak:     70,
+			warnIn:   floatPtr(1),
+			limitIn:  floatPtr(3),
+		},
+		{
+			name:     "peaked above the warning level",
+			requests: []int{85, 50, 50, 50},
+			severity: "medium",
+			peak:     85,
+		},
+		{
+			name:     "projected to reach the warning level only",
+			requests: []int{0, 2, 4, 6},
+			severity: "medium",
+			peak:     6,
+			warnIn:   floatPtr(37),
+			limitIn:  floatPtr(47),
+		},
+		{
+			name:          "throttled well below the limit",
+			requests:      []int{30, 30, 30},
+			throttled:     map[int]int{1: 5, 2: 1},
+			severity:      "medium",
+			peak:          30,
+			bursts:        1,
+			correlated:    true,
+			utilizationAt: 30,
+		},
+		{
+			name:          "throttled near the limit",
+			requests:      []int{90, 95, 90},
+			throttled:     map[int]int{0: 3, 1: 4},
+			severity:      "medium",
+			peak:          95,
+			warnIn:        floatPtr(0),
+			bursts:        2,
+			utilizationAt: 90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateRateLimit(7, "Stripe", 100, samples(tt.requests, tt.throttled), 45*time.Minute)
+
+			if got.Severity != tt.severity {
+				t.Errorf("Severity = %q, want %q (%s)", got.Severity, tt.severity, got.Warning)
+			}
+			if (got.Severity == "") != (got.Warning == "" && got.ThrottleCorrelation == "") {
+				t.Errorf("Severity %q without a matching warning", got.Severity)
+			}
+			if got.PeakPerMinute != tt.peak {
+				t.Errorf("PeakPerMinute = %d, want %d", got.PeakPerMinute, tt.peak)
+			}
+			if tt.warnIn != nil && (got.MinutesToWarn == nil || math.Abs(*got.MinutesToWarn-*tt.warnIn) > 1e-9) {
+				t.Errorf("MinutesToWarn = %v, want %v", got.MinutesToWarn, *tt.warnIn)
+			}
+			if tt.limitIn != nil && (got.MinutesToLimit == nil || math.Abs(*got.MinutesToLimit-*tt.limitIn) > 1e-9) {
+				t.Errorf("MinutesToLimit = %v, want %v", got.MinutesToLimit, *tt.limitIn)
+			}
+			if got.ThrottleBursts != tt.bursts {
+				t.Errorf("ThrottleBursts = %d, want %d", got.ThrottleBursts, tt.bursts)
+			}
+			if tt.bursts > 0 && math.Abs(got.UtilizationAt429-tt.utilizationAt) > 1e-9 {
+				t.Errorf("UtilizationAt429 = %v, want %v", got.UtilizationAt429, tt.utilizationAt)
+			}
+			if correlated := got.UtilizationAt429 > 0 && got.UtilizationAt429 < rateLimitWarnLevel; correlated != tt.correlated {
+				t.Errorf("low-utilization throttling = %v, want %v", correlated, tt.correlated)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// callerExpr identifies who made a call from its metadata, preferring the
+// most specific identifier the client sent. alias qualifies the metadata
+// column in queries that join api_requests with other tables.
+func callerExpr(alias string) string {
+	metadata := "metadata"
+	if alias != "" {
+		metadata = alias + ".metadata"
+	}
+	return fmt.Sprintf("COALESCE(%[1]s->>'service', %[1]s->>'user_id', %[1]s->>'session_id', 'unknown')", metadata)
+}
+
+const (
+	// An identical call this soon after a failure is treated as a retry.
+	defaultRetryWindow = 10 * time.Second
+	// The first retry must wait at least this long to count as backing off.
+	minRetryBackoff = 500 * time.Millisecond
+	// Each later gap may shrink to this fraction of the previous one (jitter)
+	// and still count as backing off.
+	backoffJitterTolerance = 0.8
+)
+
+// Provider-specific advice appended to retry recommendations.
+var providerRetryNotes = map[string]string{
+	"Stripe":   "Send an Idempotency-Key with retried POSTs so a retry never double-charges.",
+	"Twilio":   "Twilio bills each accepted message; only retry on 429/5xx and never on 4xx validation errors.",
+	"OpenAI":   "Honor the Retry-After and x-ratelimit-reset headers on 429s instead of a fixed delay.",
+	"SendGrid": "Retry 429s after the X-RateLimit-Reset time; duplicate sends reach recipients twice.",
+}
+
+type retryCall struct {
+	at     time.Time
+	status int
+	cost   float64
+	failed bool
+}
+
+type retryEpisode struct {
+	attempts  int
+	gaps      []time.Duration
+	extraCost float64
+	throttled bool
+	first     time.Time
+	last      time.Time
+}
+
+type RetryStorm struct {
+	OrganizationID int       `json:"organization_id"`
+	Provider       string    `json:"provider"`
+	Endpoint       string    `json:"endpoint"`
+	Method         string    `json:"method"`
+	Caller         string    `json:"caller"`
+	Episodes       int       `json:"episodes"`
+	Retries        int       `json:"retries"`
+	MaxAttempts    int       `json:"max_attempts"`
+	Amplification  float64   `json:"amplification"`
+	ExtraCost      float64   `json:"extra_cost"`
+	BackoffHonored float64   `json:"backoff_honored"`
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+type RetryPolicyReport struct {
+	OrganizationID   int          `json:"organization_id"`
+	Provider         string       `json:"provider"`
+	Episodes         int          `json:"episodes"`
+	Retries          int          `json:"retries"`
+	MaxAttempts      int          `json:"max_attempts"`
+	Amplification    float64      `json:"amplification"`
+	ExtraCost        float64      `json:"extra_cost"`
+	BackoffHonored   float64      `json:"backoff_honored"`
+	ThrottleEpisodes int          `json:"throttle_episodes"`
+	Recommendations  []string     `json:"recommendations"`
+	Storms           []RetryStorm `json:"storms"`
+}
+
+func (r RetryPolicyReport) Organization() int { return r.OrganizationID }
+
+func isFailedCall(status int, errorMessage string) bool {
+	message := strings.ToLower(errorMessage)
+	return status == 0 || status == 408 || status == 429 || status >= 500 ||
+		strings.Contains(message, "timeout") || strings.Contains(message, "timed out")
+}
+
+// splitRetryEpisodes walks one caller's identical calls in time order. A
+// failure opens an episode; every identical call within window of the
+// previous attempt is a retry, until one succeeds or the caller goes quiet.
+func splitRetryEpisodes(calls []retryCall, window time.Duration) []retryEpisode {
+	episodes := []retryEpisode{}
+	var open *retryEpisode
+
+	for _, call := range calls {
+		if open != nil && call.at.Sub(open.last) <= window {
+			open.gaps = append(open.gaps, call.at.Sub(open.last))
+			open.attempts++
+			open.extraCost += call.cost
+			open.last = call.at
+			if call.status == 429 {
+				open.throttled = true
+			}
+			if !call.failed {
+				episodes = append(episodes, *open)
+				open = nil
+			}
+			continue
+		}
+
+		if open != nil {
+			episodes = append(episodes, *open)
+			open = nil
+		}
+		if call.failed {
+			open = &retryEpisode{
+				attempts:  1,
+				throttled: call.status == 429,
+				first:     call.at,
+				last:      call.at,
+			}
+		}
+	}
+	if open != nil {
+		episodes = append(episodes, *open)
+	}
+
+	retried := episodes[:0]
+	for _, ep := range episodes {
+		if ep.attempts > 1 {
+			retried = append(retried, ep)
+		}
+	}
+	return retried
+}
+
+// backoffHonored reports whether the retries waited a minimum delay and
+// never shortened the wait beyond jitter.
+func (ep retryEpisode) backoffHonored() bool {
+	if len(ep.gaps) == 0 || ep.gaps[0] < minRetryBackoff {
+		return false
+	}
+	for i := 1; i < len(ep.gaps); i++ {
+		if float64(ep.gaps[i]) < backoffJitterTolerance*float64(ep.gaps[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectRetryStorms reconstructs retry episodes per caller. Parameters:
+// window.
+func (s *AnalyticsServer) detectRetryStorms(ctx context.Context, w Window) ([]RetryPolicyReport, error) {
+	window, err := w.Params.duration("window", jobs.EnvDuration("RETRY_WINDOW", defaultRetryWindow))
+	if err != nil {
+		return nil, fmt.Errorf("window: %w", err)
+	}
+
+	// Only call groups that failed at least once can contain retries
+	query := `
+        WITH calls AS (
+            SELECT
+                organization_id,
+                provider,
+                endpoint,
+                method,
+                ` + callerExpr("") + ` as caller,
+                MD5(endpoint || method || COALESCE(metadata::text, '')) as request_hash,
+                time,
+                COALESCE(status_code, 0) as status_code,
+                COALESCE(cost, 0) as cost,
+                COALESCE(error_message, '') as error_message
+            FROM api_requests
+            WHERE time >= $1 AND time < $2
+        ),
+        failing AS (
+            SELECT DISTINCT organization_id, caller, request_hash
+            FROM calls
+            WHERE
+                status_code IN (0, 408, 429)
+                OR status_code >= 500
+                OR error_message ILIKE '%timeout%'
+                OR error_message ILIKE '%timed out%'
+        )
+        SELECT
+            c.organization_id,
+            c.provider,
+            c.endpoint,
+            c.method,
+            c.caller,
+            c.request_hash,
+            c.time,
+            c.status_code,
+            c.cost,
+            c.error_message
+        FROM calls c
+        JOIN failing f
+            ON f.organization_id = c.organization_id
+            AND f.caller = c.caller
+            AND f.request_hash = c.request_hash
+        ORDER BY c.organization_id, c.caller, c.request_hash, c.time
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, w.From, w.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type groupKey struct {
+		orgID                       int
+		provider, endpoint          string
+		method, caller, requestHash string
+	}
+	groups := map[groupKey][]retryCall{}
+	order := []groupKey{}
+
+	for rows.Next() {
+		var key groupKey
+		var call retryCall
+		var errorMessage string
+
+		if err := rows.Scan(&key.orgID, &key.provider, &key.endpoint, &key.method, &key.caller, &key.requestHash,
+			&call.at, &call.status, &call.cost, &errorMessage); err != nil {
+			continue
+		}
+		call.failed = isFailedCall(call.status, errorMessage)
+
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], call)
+	}
+
+	// Identical calls from the same caller roll up into one storm per
+	// endpoint, and storms roll up into one report per organization and
+	// provider.
+	type stormKey struct {
+		orgID                              int
+		provider, endpoint, method, caller string
+	}
+	type reportKey struct {
+		orgID    int
+		provider string
+	}
+	storms := map[stormKey]*RetryStorm{}
+	honored := map[stormKey]int{}
+	throttled := map[reportKey]int{}
+
+	for _, key := range order {
+		for _, ep := range splitRetryEpisodes(groups[key], window) {
+			sk := stormKey{key.orgID, key.provider, key.endpoint, key.method, key.caller}
+			storm, ok := storms[sk]
+			if !ok {
+				storm = &RetryStorm{
+					OrganizationID: key.orgID,
+					Provider:       key.provider,
+					Endpoint:       key.endpoint,
+					Method:         key.method,
+					Caller:         key.caller,
+					FirstSeen:      ep.first,
+				}
+				storms[sk] = storm
+			}
+
+			storm.Episodes++
+			storm.Retries += ep.attempts - 1
+			storm.ExtraCost += ep.extraCost
+			if ep.attempts > storm.MaxAttempts {
+				storm.MaxAttempts = ep.attempts
+			}
+			if ep.first.Before(storm.FirstSeen) {
+				storm.FirstSeen = ep.first
+			}
+			if ep.last.After(storm.LastSeen) {
+				storm.LastSeen = ep.last
+			}
+			if ep.backoffHonored() {
+				honored[sk]++
+			}
+			if ep.throttled {
+				throttled[reportKey{key.orgID, key.provider}]++
+			}
+		}
+	}
+
+	reports := map[reportKey]*RetryPolicyReport{}
+	honoredByReport := map[reportKey]int{}
+	for sk, storm := range storms {
+		storm.Amplification = float64(storm.Episodes+storm.Retries) / float64(storm.Episodes)
+		storm.BackoffHonored = 100.0 * float64(honored[sk]) / float64(storm.Episodes)
+
+		rk := reportKey{storm.OrganizationID, storm.Provider}
+		report, ok := reports[rk]
+		if !ok {
+			report = &RetryPolicyReport{OrganizationID: storm.OrganizationID, Provider: storm.Provider}
+			reports[rk] = report
+		}
+		report.Episodes += storm.Episodes
+		report.Retries += storm.Retries
+		report.ExtraCost += storm.ExtraCost
+		if storm.MaxAttempts > report.MaxAttempts {
+			report.MaxAttempts = storm.MaxAttempts
+		}
+		honoredByReport[rk] += honored[sk]
+		report.Storms = append(report.Storms, *storm)
+	}
+
+	policies := []RetryPolicyReport{}
+	for rk, report := range reports {
+		report.Amplification = float64(report.Episodes+report.Retries) / float64(report.Episodes)
+		report.BackoffHonored = 100.0 * float64(honoredByReport[rk]) / float64(report.Episodes)
+		report.ThrottleEpisodes = throttled[rk]
+		report.Recommendations = retryRecommendations(*report)
+
+		sort.Slice(report.Storms, func(i, j int) bool {
+			return report.Storms[i].ExtraCost > report.Storms[j].ExtraCost
+		})
+		if len(report.Storms) > 20 {
+			report.Storms = report.Storms[:20]
+		}
+		policies = append(policies, *report)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ExtraCost > policies[j].ExtraCost
+	})
+
+	return policies, rows.Err()
+}
+
+func retryRecommendations(report RetryPolicyReport) []string {
+	recs := []string{}
+
+	if report.BackoffHonored < 50 {
+		recs = append(recs, fmt.Sprintf("Only %.0f%% of retry sequences backed off; use exponential backoff with jitter starting at %s.",
+			report.BackoffHonored, minRetryBackoff))
+	}
+	if report.MaxAttempts > 4 {
+		recs = append(recs, fmt.Sprintf("Cap retries at 3 attempts; sequences of up to %d attempts were seen.", report.MaxAttempts))
+	}
+	if report.Amplification >= 2 {
+		recs = append(recs, fmt.Sprintf("Each failure turned into %.1f calls on average; add a circuit breaker so outages don't multiply traffic.",
+			report.Amplification))
+	}
+	if report.ThrottleEpisodes*2 > report.Episodes {
+		recs = append(recs, "Most retries follow 429s; throttle client-side below the provider limit instead of retrying into it.")
+	}
+	if note, ok := providerRetryNotes[report.Provider]; ok {
+		recs = append(recs, note)
+	}
+
+	return recs
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSplitRetryEpisodes(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	call := func(seconds float64, status int) retryCall {
+		return retryCall{
+			at:     start.Add(time.Duration(seconds * float64(time.Second))),
+			status: status,
+			cost:   0.01,
+			failed: isFailedCall(status, ""),
+		}
+	}
+
+	type episode struct {
+		attempts  int
+		gaps      int
+		extraCost float64
+		throttled bool
+	}
+	tests := []struct {
+		name  string
+		calls []retryCall
+		want  []episode
+	}{
+		{"empty", nil, []episode{}},
+		{"successes only", []retryCall{call(0, 200), call(1, 200), call(2, 200)}, []episode{}},
+		{"failure never retried", []retryCall{call(0, 500)}, []episode{}},
+		{"failure retried after window", []retryCall{call(0, 500), call(11, 200)}, []episode{}},
+		{"retry at window limit", []retryCall{call(0, 500), call(10, 200)}, []episode{{2, 1, 0.01, false}}},
+		{"retried until success", []retryCall{call(0, 503), call(1, 503), call(3, 200)}, []episode{{3, 2, 0.02, false}}},
+		{"throttled retry", []retryCall{call(0, 200), call(1, 429), call(2, 429), call(4, 200)}, []episode{{3, 2, 0.02, true}}},
+		{"successes after recovery are not retries", []retryCall{call(0, 500), call(1, 200), call(2, 200)}, []episode{{2, 1, 0.01, false}}},
+		{"caller goes quiet mid episode", []retryCall{call(0, 500), call(1, 500), call(30, 500), call(31, 200)}, []episode{{2, 1, 0.01, false}, {2, 1, 0.01, false}}},
+		{"timeout with no status", []retryCall{call(0, 0), call(2, 0)}, []episode{{2, 1, 0.01, false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitRetryEpisodes(tt.calls, 10*time.Second)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitRetryEpisodes() returned %d episodes, want %d", len(got), len(tt.want))
+			}
+			for i, ep := range got {
+				want := tt.want[i]
+				if ep.attempts != want.attempts || len(ep.gaps) != want.gaps ||
+					math.Abs(ep.extraCost-want.extraCost) > 1e-9 || ep.throttled != want.throttled {
+					t.Errorf("episode %d = {attempts: %d, gaps: %d, extraCost: %v, throttled: %v}, want %+v",
+						i, ep.attempts, len(ep.gaps), ep.extraCost, ep.throttled, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffHonored(t *testing.T) {
+	tests := []struct {
+		name string
+		gaps []time.Duration
+		want bool
+	}{
+		{"no gaps", nil, false},
+		{"first retry too soon", []time.Duration{100 * time.Millisecond, time.Second}, false},
+		{"exponential", []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, true},
+		{"constant", []time.Duration{time.Second, time.Second}, true},
+		{"jitter within tolerance", []time.Duration{time.Second, 850 * time.Millisecond}, true},
+		{"shrinking", []time.Duration{2 * time.Second, time.Second}, false},
+	}
+	for _, tt := range tests {
+		if got := (retryEpisode{gaps: tt.gaps}).backoffHonored(); got != tt.want {
+			t.Errorf("%s: backoffHonored() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Maximum number of contributors attached to a spike.
+const maxRootCauses = 10
+
+type CostContributor struct {
+	Dimension    string  `json:"dimension"`
+	Value        string  `json:"value"`
+	SpikeCost    float64 `json:"spike_cost"`
+	BaselineCost float64 `json:"baseline_cost"`
+	Delta        float64 `json:"delta"`
+	Share        float64 `json:"share"`
+}
+
+type spikeDimension struct {
+	name string
+	expr string
+	arg  interface{} // extra query parameter for expr, if any
+	// group folds raw values before comparison, e.g. endpoints to templates
+	group func(string) string
+}
+
+func spikeDimensions() []spikeDimension {
+	dims := []spikeDimension{
+		{name: "provider", expr: "provider"},
+		{name: "endpoint", expr: "endpoint", group: endpointTemplate},
+		{name: "method", expr: "method"},
+		{name: "status_class", expr: `CASE
+                WHEN status_code >= 500 THEN '5xx'
+                WHEN status_code >= 400 THEN '4xx'
+                WHEN status_code >= 300 THEN '3xx'
+                WHEN status_code IS NULL THEN 'none'
+                ELSE '2xx'
+            END`},
+	}
+
+	keys := os.Getenv("ROOT_CAUSE_METADATA_KEYS")
+	if keys == "" {
+		keys = "user_id,version"
+	}
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		dims = append(dims, spikeDimension{
+			name: "metadata." + key,
+			expr: "COALESCE(metadata->>$4, '(none)')",
+			arg:  key,
+		})
+	}
+	return dims
+}
+
+// spikeBreakdownTTL keeps a settled hour's breakdown for as long as
+// detectAnomalies can score that hour.
+const spikeBreakdownTTL = 25 * time.Hour
+
+// spikeBreakdown is one dimension value's cost in the spike hour and in the
+// 24 hours before it.
+type spikeBreakdown struct {
+	Dimension string  `json:"dimension"`
+	Value     string  `json:"value"`
+	SpikeCost float64 `json:"spike_cost"`
+	PriorCost float64 `json:"prior_cost"`
+}
+
+// attributeCostSpike breaks the spike hour of an org down by dimension and
+// returns the values that grew the most. Each value's baseline is its share
+// of the previous 24 hours scaled to expected, the seasonal baseline of the
+// spike hour, so the deltas add up to the excess the anomaly reports.
+// Breakdowns of hours that ended before now are cached, so each run only
+// scans api_requests for new spikes and the hour still in progress.
+func (s *AnalyticsServer) attributeCostSpike(ctx context.Context, orgID int, hour, now time.Time, observed, expected float64) ([]CostContributor, error) {
+	if observed <= expected {
+		return nil, nil
+	}
+
+	settled := !hour.Add(time.Hour).After(now)
+	key := fmt.Sprintf("analytics:spike_breakdown:%d:%d", orgID, hour.Unix())
+	if settled {
+		if data, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+			var breakdown []spikeBreakdown
+			if err := json.Unmarshal(data, &breakdown); err == nil {
+				return costContributors(breakdown, observed, expected), nil
+			}
+		}
+	}
+
+	end := hour.Add(time.Hour)
+	if !settled {
+		end = now
+	}
+	breakdown, err := s.loadSpikeBreakdown(ctx, orgID, hour, end)
+	if err != nil {
+		return nil, err
+	}
+	if settled {
+		if data, err := json.Marshal(breakdown); err == nil {
+			if err := s.redis.Set(ctx, key, data, spikeBreakdownTTL).Err(); err != nil {
+				log.Printf("Failed to cache spike breakdown for org %d: %v", orgID, err)
+			}
+		}
+	}
+	return costContributors(breakdown, observed, expected), nil
+}
+
+// loadSpikeBreakdown sums an org's cost per dimension value for the spike
+// hour up to end and the 24 hours before it.
+func (s *AnalyticsServer) loadSpikeBreakdown(ctx context.Context, orgID int, hour, end time.Time) ([]spikeBreakdown, error) {
+	breakdown := []spikeBreakdown{}
+	for _, dim := range spikeDimensions() {
+		query := `
+            SELECT
+                ` + dim.expr + ` as value,
+                COALESCE(SUM(cost) FILTER (WHERE time >= $2), 0) as spike_cost,
+                COALESCE(SUM(cost) FILTER (WHERE time < $2), 0) as prior_cost
+            FROM api_requests
+            WHERE
+                organization_id = $1
+                AND time >= $2::timestamptz - INTERVAL '24 hours'
+                AND time < $3
+            GROUP BY 1
+        `
+		args := []interface{}{orgID, hour, end}
+		if dim.arg != nil {
+			args = append(args, dim.arg)
+		}
+
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		byValue := map[string]*spikeBreakdown{}
+		order := []string{}
+		for rows.Next() {
+			var value string
+			var spikeCost, priorCost float64
+			if err := rows.Scan(&value, &spikeCost, &priorCost); err != nil {
+				continue
+			}
+			if dim.group != nil {
+				value = dim.group(value)
+			}
+			b, ok := byValue[value]
+			if !ok {
+				b = &spikeBreakdown{Dimension: dim.name, Value: value}
+				byValue[value] = b
+				order = append(order, value)
+			}
+			b.SpikeCost += spikeCost
+			b.PriorCost += priorCost
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, value := range order {
+			breakdown = append(breakdown, *byValue[value])
+		}
+	}
+	return breakdown, nil
+}
+
+// costContributors scales each dimension's prior shares to expected and
+// returns the values whose spike-hour cost exceeds their share the most.
+func costContributors(breakdown []spikeBreakdown, observed, expected float64) []CostContributor {
+	excess := observed - expected
+	if excess <= 0 {
+		return nil
+	}
+
+	priorTotal := map[string]float64{}
+	for _, b := range breakdown {
+		priorTotal[b.Dimension] += b.PriorCost
+	}
+
+	contributors := []CostContributor{}
+	for _, b := range breakdown {
+		baseline := 0.0
+		if total := priorTotal[b.Dimension]; total > 0 {
+			baseline = expected * b.PriorCost / total
+		}
+		delta := b.SpikeCost - baseline
+		if delta <= 0 {
+			continue
+		}
+		contributors = append(contributors, CostContributor{
+			Dimension:    b.Dimension,
+			Value:        b.Value,
+			SpikeCost:    b.SpikeCost,
+			BaselineCost: baseline,
+			Delta:        delta,
+			Share:        100.0 * delta / excess,
+		})
+	}
+
+	sort.Slice(contributors, func(i, j int) bool {
+		return contributors[i].Delta > contributors[j].Delta
+	})
+	if len(contributors) > maxRootCauses {
+		contributors = contributors[:maxRootCauses]
+	}
+	return contributors
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostContributors(t *testing.T) {
+	breakdown := []spikeBreakdown{
+		{Dimension: "provider", Value: "OpenAI", SpikeCost: 9, PriorCost: 60},
+		{Dimension: "provider", Value: "Stripe", SpikeCost: 1, PriorCost: 40},
+		{Dimension: "status_class", Value: "2xx", SpikeCost: 4, PriorCost: 100},
+		{Dimension: "status_class", Value: "none", SpikeCost: 5, PriorCost: 0},
+		{Dimension: "metadata.version", Value: "(none)", SpikeCost: 0, PriorCost: 0},
+	}
+
+	// Expected 5 and observed 10: OpenAI's baseline is 60% of 5, Stripe's
+	// 40%; all of status_class's prior spend was 2xx.
+	got := costContributors(breakdown, 10, 5)
+	want := []CostContributor{
+		{Dimension: "provider", Value: "OpenAI", SpikeCost: 9, BaselineCost: 3, Delta: 6, Share: 120},
+		{Dimension: "status_class", Value: "none", SpikeCost: 5, BaselineCost: 0, Delta: 5, Share: 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("costContributors() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Dimension != w.Dimension || g.Value != w.Value || math.Abs(g.BaselineCost-w.BaselineCost) > 1e-9 ||
+			math.Abs(g.Delta-w.Delta) > 1e-9 || math.Abs(g.Share-w.Share) > 1e-9 {
+			t.Errorf("contributor %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	if got := costContributors(breakdown, 5, 5); got != nil {
+		t.Errorf("costContributors() without excess = %+v, want nil", got)
+	}
+	if got := costContributors(nil, 10, 5); len(got) != 0 {
+		t.Errorf("costContributors(nil) = %+v, want none", got)
+	}
+}
+
+func TestCostContributorsLimit(t *testing.T) {
+	breakdown := []spikeBreakdown{}
+	for i := 0; i < maxRootCauses+5; i++ {
+		breakdown = append(breakdown, spikeBreakdown{Dimension: "endpoint", Value: string(rune('a' + i)), SpikeCost: float64(i + 1)})
+	}
+	got := costContributors(breakdown, 200, 100)
+	if len(got) != maxRootCauses {
+		t.Fatalf("costContributors() returned %d contributors, want %d", len(got), maxRootCauses)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Delta > got[i-1].Delta {
+			t.Fatalf("contributors not sorted by delta: %+v", got)
+		}
+	}
+	if got[0].Value != string(rune('a'+maxRootCauses+4)) {
+		t.Errorf("largest contributor = %q", got[0].Value)
+	}
+}
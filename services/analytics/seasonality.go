@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Robust z-score above which an hour is a cost spike, unless the org
+	// overrides it in anomaly_settings.
+	defaultSpikeSensitivity = 3.5
+	// Smallest scale a slot may have, as a fraction of its median, unless
+	// the org overrides it in anomaly_settings.
+	defaultSpikeScaleFloor = 0.1
+	// A seasonal slot needs this many samples before its own median/MAD is
+	// trusted; sparser slots fall back to the hour-of-day slot.
+	minSlotSamples = 3
+	// Scales MAD to be comparable with a standard deviation.
+	madScale = 1.4826
+)
+
+type hourlyCost struct {
+	orgID    int
+	hour     time.Time
+	cost     float64
+	requests int
+}
+
+// spikeSettings are an org's cost-spike settings from anomaly_settings.
+type spikeSettings struct {
+	sensitivity float64
+	scaleFloor  float64
+}
+
+func defaultSpikeSettings() spikeSettings {
+	return spikeSettings{sensitivity: defaultSpikeSensitivity, scaleFloor: defaultSpikeScaleFloor}
+}
+
+// seasonalBaseline holds historical hourly costs bucketed by hour-of-week and
+// hour-of-day in UTC, so a Monday 09:00 is compared with previous Monday
+// mornings rather than with last night. Slots are UTC so they don't depend
+// on the host's time zone or shift with daylight saving time.
+type seasonalBaseline struct {
+	byWeekSlot map[int][]float64
+	byHour     map[int][]float64
+	all        []float64
+}
+
+type baselineEstimate struct {
+	median float64
+	scale  float64
+	slot   string
+}
+
+func newSeasonalBaseline(history []hourlyCost) *seasonalBaseline {
+	b := &seasonalBaseline{
+		byWeekSlot: map[int][]float64{},
+		byHour:     map[int][]float64{},
+	}
+	for _, h := range history {
+		utc := h.hour.UTC()
+		slot := int(utc.Weekday())*24 + utc.Hour()
+		b.byWeekSlot[slot] = append(b.byWeekSlot[slot], h.cost)
+		b.byHour[utc.Hour()] = append(b.byHour[utc.Hour()], h.cost)
+		b.all = append(b.all, h.cost)
+	}
+	return b
+}
+
+// estimate returns the robust location and scale for the seasonal slot t
+// falls in, or false if there is not enough history at all. The scale is at
+// least scaleFloor times the median.
+func (b *seasonalBaseline) estimate(t time.Time, scaleFloor float64) (baselineEstimate, bool) {
+	utc := t.UTC()
+
+	samples := b.byWeekSlot[int(utc.Weekday())*24+utc.Hour()]
+	slot := utc.Format("Mon 15:00 UTC")
+	if len(samples) < minSlotSamples {
+		samples = b.byHour[utc.Hour()]
+		slot = utc.Format("15:00 UTC")
+	}
+	if len(samples) < minSlotSamples {
+		samples = b.all
+		slot = "all hours"
+	}
+	if len(samples) < minSlotSamples {
+		return baselineEstimate{}, false
+	}
+
+	median := medianOf(samples)
+	deviations := make([]float64, len(samples))
+	for i, v := range samples {
+		deviations[i] = math.Abs(v - median)
+	}
+	scale := madScale * medianOf(deviations)
+
+	// A flat slot has MAD 0; fall back to a fraction of the level so a small
+	// wobble on a perfectly steady series doesn't score as infinite.
+	if floor := scaleFloor * median; scale < floor {
+		scale = floor
+	}
+	if scale == 0 {
+		scale = 1e-6
+	}
+
+	return baselineEstimate{median: median, scale: scale, slot: slot}, true
+}
+
+// score returns the robust z-score of value against the slot baseline.
+func (e baselineEstimate) score(value float64) float64 {
+	return (value - e.median) / e.scale
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// fillHours inserts zero-cost hours between the first and last observed hour
+// of each org, since api_costs_hourly has no row for idle hours.
+func fillHours(history []hourlyCost) []hourlyCost {
+	type span struct{ first, last time.Time }
+	spans := map[int]*span{}
+	seen := map[int]map[int64]bool{}
+	for _, h := range history {
+		sp, ok := spans[h.orgID]
+		if !ok {
+			spans[h.orgID] = &span{first: h.hour, last: h.hour}
+			seen[h.orgID] = map[int64]bool{}
+		} else {
+			if h.hour.Before(sp.first) {
+				sp.first = h.hour
+			}
+			if h.hour.After(sp.last) {
+				sp.last = h.hour
+			}
+		}
+		seen[h.orgID][h.hour.Unix()] = true
+	}
+
+	filled := append([]hourlyCost(nil), history...)
+	for orgID, sp := range spans {
+		for t := sp.first; t.Before(sp.last); t = t.Add(time.Hour) {
+			if !seen[orgID][t.Unix()] {
+				filled = append(filled, hourlyCost{orgID: orgID, hour: t})
+			}
+		}
+	}
+	return filled
+}
+
+// loadCostHistory reads hourly cost per org from the api_costs_hourly
+// continuous aggregate for [from, to).
+func (s *AnalyticsServer) loadCostHistory(ctx context.Context, from, to time.Time) (map[int][]hourlyCost, error) {
+	query := `
+        SELECT
+            organization_id,
+            bucket,
+            SUM(total_cost) as hourly_cost,
+            SUM(request_count) as request_count
+        FROM api_costs_hourly
+        WHERE bucket >= $1 AND bucket < $2
+        GROUP BY organization_id, bucket
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []hourlyCost{}
+	for rows.Next() {
+		var h hourlyCost
+		if err := rows.Scan(&h.orgID, &h.hour, &h.cost, &h.requests); err != nil {
+			continue
+		}
+		history = append(history, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	byOrg := map[int][]hourlyCost{}
+	for _, h := range fillHours(history) {
+		byOrg[h.orgID] = append(byOrg[h.orgID], h)
+	}
+	return byOrg, nil
+}
+
+// loadSpikeSettings returns the per-org settings from anomaly_settings.
+// Orgs without a row use defaultSpikeSettings.
+func (s *AnalyticsServer) loadSpikeSettings(ctx context.Context) (map[int]spikeSettings, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT organization_id, cost_spike_sensitivity, cost_spike_scale_floor FROM anomaly_settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[int]spikeSettings{}
+	for rows.Next() {
+		var orgID int
+		var value spikeSettings
+		if err := rows.Scan(&orgID, &value.sensitivity, &value.scaleFloor); err != nil {
+			continue
+		}
+		settings[orgID] = value
+	}
+	return settings, rows.Err()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+// monday is 2025-01-06, a Monday, at midnight UTC.
+var monday = time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+func costsAt(cost float64, hours ...time.Time) []hourlyCost {
+	history := []hourlyCost{}
+	for _, h := range hours {
+		history = append(history, hourlyCost{orgID: 1, hour: h, cost: cost})
+	}
+	return history
+}
+
+func TestSeasonalBaselineFallback(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	nine := monday.Add(9 * time.Hour)
+	target := nine.Add(3 * week)
+
+	t.Run("week slot", func(t *testing.T) {
+		history := append(costsAt(10, nine), costsAt(12, nine.Add(week))...)
+		history = append(history, costsAt(14, nine.Add(2*week))...)
+		// Other days at 09:00 don't dilute a populated week slot.
+		history = append(history, costsAt(100, nine.Add(24*time.Hour), nine.Add(48*time.Hour))...)
+
+		e, ok := newSeasonalBaseline(history).estimate(target, 0)
+		if !ok || e.slot != "Mon 09:00 UTC" || e.median != 12 || math.Abs(e.scale-2*madScale) > 1e-9 {
+			t.Errorf("estimate() = %+v, %v; want median 12, scale %v in the Monday slot", e, ok, 2*madScale)
+		}
+	})
+
+	t.Run("hour of day", func(t *testing.T) {
+		history := costsAt(10, nine, nine.Add(week), nine.Add(24*time.Hour))
+		e, ok := newSeasonalBaseline(history).estimate(target, 0)
+		if !ok || e.slot != "09:00 UTC" || e.median != 10 {
+			t.Errorf("estimate() = %+v, %v; want the 09:00 slot", e, ok)
+		}
+	})
+
+	t.Run("all hours", func(t *testing.T) {
+		history := costsAt(10, nine, nine.Add(week), monday.Add(15*time.Hour))
+		e, ok := newSeasonalBaseline(history).estimate(target, 0)
+		if !ok || e.slot != "all hours" {
+			t.Errorf("estimate() = %+v, %v; want all hours", e, ok)
+		}
+	})
+
+	t.Run("not enough history", func(t *testing.T) {
+		if e, ok := newSeasonalBaseline(costsAt(10, nine, nine.Add(week))).estimate(target, 0); ok {
+			t.Errorf("estimate() = %+v from two samples, want none", e)
+		}
+	})
+
+	t.Run("slots are UTC", func(t *testing.T) {
+		tokyo := time.FixedZone("JST", 9*60*60)
+		history := costsAt(10, nine.In(tokyo), nine.Add(week).In(tokyo), nine.Add(2*week).In(tokyo))
+		e, ok := newSeasonalBaseline(history).estimate(target.In(tokyo), 0)
+		if !ok || e.slot != "Mon 09:00 UTC" {
+			t.Errorf("estimate() = %+v, %v; want the UTC Monday slot", e, ok)
+		}
+	})
+}
+
+func TestSeasonalBaselineScaleFloor(t *testing.T) {
+	at := monday.Add(9 * time.Hour)
+	flat := costsAt(10, at, at.Add(time.Hour), at.Add(2*time.Hour))
+	noisy := append(costsAt(8, at), append(costsAt(10, at.Add(time.Hour)), costsAt(12, at.Add(2*time.Hour))...)...)
+	idle := costsAt(0, at, at.Add(time.Hour), at.Add(2*time.Hour))
+
+	tests := []struct {
+		name    string
+		history []hourlyCost
+		floor   float64
+		scale   float64
+	}{
+		{"flat series uses the floor", flat, 0.1, 1},
+		{"larger floor", flat, 0.5, 5},
+		{"spread above the floor", noisy, 0.1, 2 * madScale},
+		{"floor above the spread", noisy, 0.5, 5},
+		{"no floor on a flat series", flat, 0, 1e-6},
+		{"idle series", idle, 0.1, 1e-6},
+	}
+	for _, tt := range tests {
+		e, ok := newSeasonalBaseline(tt.history).estimate(at, tt.floor)
+		if !ok || math.Abs(e.scale-tt.scale) > 1e-9 {
+			t.Errorf("%s: scale = %v, want %v", tt.name, e.scale, tt.scale)
+		}
+	}
+}
+
+func TestFillHours(t *testing.T) {
+	h := func(org, hour int, cost float64) hourlyCost {
+		return hourlyCost{orgID: org, hour: monday.Add(time.Duration(hour) * time.Hour), cost: cost}
+	}
+	history := []hourlyCost{h(1, 3, 5), h(2, 1, 7), h(1, 0, 2), h(2, 2, 1), h(3, 4, 9)}
+
+	got := map[int][]float64{}
+	filled := fillHours(history)
+	sort.Slice(filled, func(i, j int) bool { return filled[i].hour.Before(filled[j].hour) })
+	for _, c := range filled {
+		got[c.orgID] = append(got[c.orgID], c.cost)
+	}
+
+	want := map[int][]float64{
+		1: {2, 0, 0, 5}, // hours 1 and 2 were idle
+		2: {7, 1},
+		3: {9},
+	}
+	for org, costs := range want {
+		if len(got[org]) != len(costs) {
+			t.Errorf("org %d filled to %v, want %v", org, got[org], costs)
+			continue
+		}
+		for i := range costs {
+			if got[org][i] != costs[i] {
+				t.Errorf("org %d filled to %v, want %v", org, got[org], costs)
+				break
+			}
+		}
+	}
+	if len(fillHours(nil)) != 0 {
+		t.Errorf("fillHours(nil) is not empty")
+	}
+}
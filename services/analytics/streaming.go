@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"github.com/yourusername/api-observatory/shared/tenant"
+)
+
+// streamEvent is the "new_request" message ingestion publishes on api_events.
+type streamEvent struct {
+	Type           string  `json:"type"`
+	RequestID      string  `json:"request_id"`
+	OrganizationID string  `json:"organization_id"`
+	Provider       string  `json:"provider"`
+	Endpoint       string  `json:"endpoint"`
+	Method         string  `json:"method"`
+	StatusCode     int     `json:"status_code"`
+	LatencyMS      int     `json:"latency_ms"`
+	Cost           float64 `json:"cost"`
+	Timestamp      int64   `json:"timestamp"` // unix millis
+}
+
+type StreamConfig struct {
+	BucketWidth       time.Duration
+	ShortWindow       time.Duration
+	LongWindow        time.Duration
+	MinRequests       int
+	ErrorRatio        float64
+	CostMultiplier    float64
+	LatencyMultiplier float64
+	Cooldown          time.Duration
+}
+
+func defaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		BucketWidth:       5 * time.Second,
+		ShortWindow:       jobs.EnvDuration("STREAM_SHORT_WINDOW", time.Minute),
+		LongWindow:        jobs.EnvDuration("STREAM_LONG_WINDOW", 15*time.Minute),
+		MinRequests:       20,
+		ErrorRatio:        0.2,
+		CostMultiplier:    3,
+		LatencyMultiplier: 2,
+		Cooldown:          5 * time.Minute,
+	}
+}
+
+// latencySketch is a log-bucketed histogram: bin i covers latencies up to
+// sketchGamma^i ms, so quantiles are accurate to about 5% and two sketches
+// merge by adding bins.
+type latencySketch struct {
+	bins  [sketchBins]uint32
+	count uint32
+}
+
+const (
+	sketchGamma = 1.1
+	sketchBins  = 160 // 1.1^160 ms is well beyond any realistic timeout
+)
+
+var logSketchGamma = math.Log(sketchGamma)
+
+func (s *latencySketch) add(latencyMS int) {
+	bin := 0
+	if latencyMS > 1 {
+		bin = int(math.Ceil(math.Log(float64(latencyMS)) / logSketchGamma))
+	}
+	if bin >= sketchBins {
+		bin = sketchBins - 1
+	}
+	s.bins[bin]++
+	s.count++
+}
+
+func (s *latencySketch) merge(other *latencySketch) {
+	for i, n := range other.bins {
+		s.bins[i] += n
+	}
+	s.count += other.count
+}
+
+func (s *latencySketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := uint32(math.Ceil(q * float64(s.count)))
+	var seen uint32
+	for i, n := range s.bins {
+		seen += n
+		if seen >= rank {
+			return math.Pow(sketchGamma, float64(i))
+		}
+	}
+	return math.Pow(sketchGamma, sketchBins-1)
+}
+
+type windowBucket struct {
+	index    int64 // event time / bucket width; identifies which slot is live
+	requests int
+	errors   int
+	cost     float64
+	latency  latencySketch
+}
+
+type windowAggregate struct {
+	Requests   int
+	Errors     int
+	Cost       float64
+	Seconds    float64
+	latency    latencySketch
+	P95Latency float64
+}
+
+func (a windowAggregate) errorRatio() float64 {
+	if a.Requests == 0 {
+		return 0
+	}
+	return float64(a.Errors) / float64(a.Requests)
+}
+
+func (a windowAggregate) costRate() float64 {
+	if a.Seconds == 0 {
+		return 0
+	}
+	return a.Cost / a.Seconds
+}
+
+// slidingWindow is a ring of fixed-width buckets covering the long window.
+type slidingWindow struct {
+	buckets  []windowBucket
+	lastSeen int64
+}
+
+type streamKey struct {
+	orgID    int
+	provider string
+}
+
+// StreamEngine keeps per org/provider sliding-window aggregates over the live
+// event feed and compares the short window with the long window preceding it.
+// Time is driven by event timestamps, so the same engine replays history.
+type StreamEngine struct {
+	mu        sync.Mutex
+	config    StreamConfig
+	windows   map[streamKey]*slidingWindow
+	lastAlert map[string]time.Time
+}
+
+func NewStreamEngine(config StreamConfig) *StreamEngine {
+	return &StreamEngine{
+		config:    config,
+		windows:   map[streamKey]*slidingWindow{},
+		lastAlert: map[string]time.Time{},
+	}
+}
+
+func (e *StreamEngine) bucketCount() int64 {
+	return int64((e.config.ShortWindow + e.config.LongWindow) / e.config.BucketWidth)
+}
+
+func (e *StreamEngine) Observe(ev streamEvent) {
+	orgID, err := strconv.Atoi(ev.OrganizationID)
+	if err != nil {
+		return // anomalies must belong to an organization
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := streamKey{orgID: orgID, provider: ev.Provider}
+	w, ok := e.windows[key]
+	if !ok {
+		w = &slidingWindow{buckets: make([]windowBucket, e.bucketCount())}
+		e.windows[key] = w
+	}
+
+	index := ev.Timestamp / e.config.BucketWidth.Milliseconds()
+	b := &w.buckets[index%int64(len(w.buckets))]
+	if b.index != index {
+		if b.index > index {
+			return // older than the window; nothing left to update
+		}
+		*b = windowBucket{index: index}
+	}
+
+	b.requests++
+	if ev.StatusCode >= 400 {
+		b.errors++
+	}
+	b.cost += ev.Cost
+	b.latency.add(ev.LatencyMS)
+	if index > w.lastSeen {
+		w.lastSeen = index
+	}
+}
+
+// aggregate sums buckets with from < index <= to.
+func (e *StreamEngine) aggregate(w *slidingWindow, from, to int64) windowAggregate {
+	agg := windowAggregate{Seconds: float64(to-from) * e.config.BucketWidth.Seconds()}
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.index <= from || b.index > to {
+			continue
+		}
+		agg.Requests += b.requests
+		agg.Errors += b.errors
+		agg.Cost += b.cost
+		agg.latency.merge(&b.latency)
+	}
+	agg.P95Latency = agg.latency.quantile(0.95)
+	return agg
+}
+
+// Evaluate checks every key as of now and returns anomalies that are not in
+// cooldown. Keys with no traffic for a full window, and cooldowns that have
+// expired, are dropped.
+func (e *StreamEngine) Evaluate(now time.Time) []Anomaly {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	width := e.config.BucketWidth.Milliseconds()
+	nowIndex := now.UnixMilli() / width
+	shortBuckets := int64(e.config.ShortWindow / e.config.BucketWidth)
+
+	// Expired cooldowns would otherwise accumulate one entry per key and type
+	for alertKey, last := range e.lastAlert {
+		if now.Sub(last) >= e.config.Cooldown {
+			delete(e.lastAlert, alertKey)
+		}
+	}
+
+	anomalies := []Anomaly{}
+	for key, w := range e.windows {
+		if nowIndex-w.lastSeen > e.bucketCount() {
+			delete(e.windows, key)
+			continue
+		}
+
+		short := e.aggregate(w, nowIndex-shortBuckets, nowIndex)
+		long := e.aggregate(w, nowIndex-e.bucketCount(), nowIndex-shortBuckets)
+
+		for _, a := range e.checkWindow(key, short, long, now) {
+			alertKey := fmt.Sprintf("%s:%d:%s", a.Type, key.orgID, key.provider)
+			if last, ok := e.lastAlert[alertKey]; ok && now.Sub(last) < e.config.Cooldown {
+				continue
+			}
+			e.lastAlert[alertKey] = now
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+func (e *StreamEngine) checkWindow(key streamKey, short, long windowAggregate, now time.Time) []Anomaly {
+	cfg := e.config
+	if short.Requests < cfg.MinRequests {
+		return nil
+	}
+
+	orgID := key.orgID
+	found := []Anomaly{}
+
+	if ratio := short.errorRatio(); ratio >= cfg.ErrorRatio && ratio >= 2*long.errorRatio() {
+		found = append(found, Anomaly{
+			OrganizationID: orgID,
+			Provider:       key.provider,
+			Type:           "error_surge",
+			Severity:       "high",
+			Description: fmt.Sprintf("%s error ratio %.0f%% over the last %s (baseline %.0f%%).",
+				key.provider, ratio*100, cfg.ShortWindow, long.errorRatio()*100),
+			DetectedAt: now,
+		})
+	}
+
+	// The cost and latency checks need a baseline with enough traffic to be
+	// meaningful; a provider that just started receiving calls has none.
+	if long.Requests < cfg.MinRequests {
+		return found
+	}
+
+	if rate, base := short.costRate(), long.costRate(); base > 0 && rate >= cfg.CostMultiplier*base {
+		found = append(found, Anomaly{
+			OrganizationID: orgID,
+			Provider:       key.provider,
+			Type:           "live_cost_spike",
+			Severity:       "high",
+			Description: fmt.Sprintf("%s spending $%.4f/min over the last %s, %.1fx the preceding %s.",
+				key.provider, rate*60, cfg.ShortWindow, rate/base, cfg.LongWindow),
+			DetectedAt: now,
+		})
+	}
+
+	if long.P95Latency > 0 && short.P95Latency >= cfg.LatencyMultiplier*long.P95Latency {
+		found = append(found, Anomaly{
+			OrganizationID: orgID,
+			Provider:       key.provider,
+			Type:           "latency_regression",
+			Severity:       "medium",
+			Description: fmt.Sprintf("%s p95 latency %.0fms over the last %s (baseline %.0fms).",
+				key.provider, short.P95Latency, cfg.ShortWindow, long.P95Latency),
+			DetectedAt: now,
+		})
+	}
+
+	return found
+}
+
+// runStreamingAnalysis backfills the engine from api_requests, then follows
+// the live api_events feed and evaluates every bucket width.
+func (s *AnalyticsServer) runStreamingAnalysis() {
+	ctx := context.Background()
+	engine := NewStreamEngine(defaultStreamConfig())
+
+	// Subscribe before backfilling so no events fall in the gap. Events the
+	// backfill already read from api_requests are skipped by request id.
+	pubsub := s.redis.Subscribe(ctx, "api_events")
+	defer pubsub.Close()
+
+	until, seen, err := s.backfillStream(ctx, engine)
+	if err != nil {
+		log.Printf("Failed to backfill streaming engine: %v", err)
+	}
+	filter := &backfillFilter{until: until.UnixMilli(), seen: seen, expires: time.Now().Add(streamBackfillOverlap)}
+
+	ticker := time.NewTicker(engine.config.BucketWidth)
+	defer ticker.Stop()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case msg := <-ch:
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil || ev.Type != "new_request" {
+				continue
+			}
+			if filter.skip(ev, time.Now()) {
+				continue
+			}
+			engine.Observe(ev)
+		case now := <-ticker.C:
+			if fired := engine.Evaluate(now); len(fired) > 0 {
+				s.publishLiveAnomalies(ctx, fired, engine.config.Cooldown)
+			}
+		}
+	}
+}
+
+// Live events published this long after the backfill finished cannot have
+// been in its snapshot, since ingestion publishes right after inserting.
+const streamBackfillOverlap = time.Minute
+
+// backfillFilter drops live events the stream backfill already read. A
+// request stamped before the backfill's upper bound but committed after its
+// snapshot only arrives live, so events are matched by request id rather
+// than by timestamp.
+type backfillFilter struct {
+	until   int64           // the backfill's upper bound, unix millis
+	seen    map[string]bool // request ids the backfill read
+	expires time.Time
+}
+
+func (f *backfillFilter) skip(ev streamEvent, now time.Time) bool {
+	if f.seen == nil || ev.Timestamp > f.until {
+		return false
+	}
+	if now.After(f.expires) {
+		f.seen = nil // nothing still to arrive can overlap the backfill
+		return false
+	}
+	if ev.RequestID == "" {
+		return true // can't tell, and double counting would skew the windows
+	}
+	return f.seen[ev.RequestID]
+}
+
+// maxLiveAnomalies caps each organization's analytics:live_anomalies list.
+const maxLiveAnomalies = 100
+
+// publishLiveAnomalies announces each anomaly on api_events and pushes it
+// onto its organization's analytics:live_anomalies list. The SETNX guard
+// keeps replicas that see the same feed from announcing or recording the
+// same anomaly twice.
+func (s *AnalyticsServer) publishLiveAnomalies(ctx context.Context, fired []Anomaly, cooldown time.Duration) {
+	for _, a := range fired {
+		guard := fmt.Sprintf("analytics:live_alert:%s:%d:%s", a.Type, a.OrganizationID, a.Provider)
+		if ok, err := s.redis.SetNX(ctx, guard, 1, cooldown).Result(); err != nil || !ok {
+			continue
+		}
+
+		data, _ := json.Marshal(a)
+		key := tenant.Key("analytics:live_anomalies", a.OrganizationID)
+		pipe := s.redis.Pipeline()
+		pipe.LPush(ctx, key, data)
+		pipe.LTrim(ctx, key, 0, maxLiveAnomalies-1)
+		pipe.Expire(ctx, key, 1*time.Hour)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Failed to record live anomaly: %v", err)
+		}
+
+		event, _ := json.Marshal(map[string]interface{}{
+			"type":    "anomaly",
+			"anomaly": a,
+		})
+		s.redis.Publish(ctx, "api_events", event)
+		log.Printf("Live anomaly for org %d: %s", a.OrganizationID, a.Description)
+	}
+}
+
+// backfillStream loads the last long+short window of requests so the engine
+// has a baseline immediately after a restart. It returns the window's upper
+// bound, after which requests are left to the live feed, and the ids of the
+// requests it read.
+func (s *AnalyticsServer) backfillStream(ctx context.Context, engine *StreamEngine) (time.Time, map[string]bool, error) {
+	until := time.Now()
+	since := until.Add(-(engine.config.ShortWindow + engine.config.LongWindow))
+
+	query := `
+        SELECT
+            request_id,
+            organization_id,
+            provider,
+            endpoint,
+            method,
+            COALESCE(status_code, 0),
+            COALESCE(latency_ms, 0),
+            COALESCE(cost, 0),
+            time
+        FROM api_requests
+        WHERE time > $1 AND time <= $2
+        ORDER BY time
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, since, until)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	count := 0
+	for rows.Next() {
+		var ev streamEvent
+		var orgID int
+		var t time.Time
+		if err := rows.Scan(&ev.RequestID, &orgID, &ev.Provider, &ev.Endpoint, &ev.Method, &ev.StatusCode, &ev.LatencyMS, &ev.Cost, &t); err != nil {
+			continue
+		}
+		ev.OrganizationID = strconv.Itoa(orgID)
+		ev.Timestamp = t.UnixMilli()
+		engine.Observe(ev)
+		if ev.RequestID != "" {
+			seen[ev.RequestID] = true
+		}
+		count++
+	}
+
+	log.Printf("Backfilled streaming engine with %d requests", count)
+	return until, seen, rows.Err()
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testStreamConfig() StreamConfig {
+	return StreamConfig{
+		BucketWidth:       5 * time.Second,
+		ShortWindow:       time.Minute,
+		LongWindow:        4 * time.Minute,
+		MinRequests:       20,
+		ErrorRatio:        0.2,
+		CostMultiplier:    3,
+		LatencyMultiplier: 2,
+		Cooldown:          5 * time.Minute,
+	}
+}
+
+var streamStart = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func streamAt(at time.Duration, status, latencyMS int, cost float64) streamEvent {
+	return streamEvent{
+		Type:           "new_request",
+		OrganizationID: "1",
+		Provider:       "Stripe",
+		StatusCode:     status,
+		LatencyMS:      latencyMS,
+		Cost:           cost,
+		Timestamp:      streamStart.Add(at).UnixMilli(),
+	}
+}
+
+func TestLatencySketch(t *testing.T) {
+	var empty latencySketch
+	if q := empty.quantile(0.95); q != 0 {
+		t.Errorf("empty quantile = %v, want 0", q)
+	}
+
+	var low, high latencySketch
+	for ms := 1; ms <= 500; ms++ {
+		low.add(ms)
+	}
+	for ms := 501; ms <= 1000; ms++ {
+		high.add(ms)
+	}
+	low.merge(&high)
+	if low.count != 1000 {
+		t.Fatalf("merged count = %d, want 1000", low.count)
+	}
+	for _, c := range []struct{ q, want float64 }{{0.5, 500}, {0.95, 950}, {1, 1000}} {
+		if got := low.quantile(c.q); math.Abs(got-c.want)/c.want > 0.1 {
+			t.Errorf("quantile(%v) = %.0f, want about %.0f", c.q, got, c.want)
+		}
+	}
+
+	var huge latencySketch
+	huge.add(math.MaxInt32)
+	if got := huge.quantile(1); got != math.Pow(sketchGamma, sketchBins-1) {
+		t.Errorf("out-of-range latency lands in bin %v, want the last", got)
+	}
+}
+
+func TestStreamEngineBuckets(t *testing.T) {
+	e := NewStreamEngine(testStreamConfig())
+	key := streamKey{orgID: 1, provider: "Stripe"}
+	span := int64(e.bucketCount())
+	// Requests in the window ending at the newest bucket seen.
+	total := func() int {
+		w := e.windows[key]
+		return e.aggregate(w, w.lastSeen-span, w.lastSeen).Requests
+	}
+
+	e.Observe(streamAt(0, 200, 100, 0.01))
+	e.Observe(streamAt(time.Second, 200, 100, 0.01))
+	if got := total(); got != 2 {
+		t.Fatalf("requests after two events = %d, want 2", got)
+	}
+
+	// One full ring later the same slot is reused for the new bucket.
+	e.Observe(streamAt(time.Duration(span)*5*time.Second, 200, 100, 0.01))
+	if got := total(); got != 1 {
+		t.Errorf("requests after rollover = %d, want 1", got)
+	}
+
+	// An event for the overwritten bucket arrives too late to be counted...
+	e.Observe(streamAt(2*time.Second, 200, 100, 0.01))
+	if got := total(); got != 1 {
+		t.Errorf("requests after late event = %d, want 1", got)
+	}
+
+	// ...but a late event still inside the window lands in its own bucket.
+	e.Observe(streamAt(10*time.Second, 500, 100, 0.01))
+	if got := total(); got != 2 {
+		t.Errorf("requests after in-window late event = %d, want 2", got)
+	}
+}
+
+func TestStreamEngineCooldown(t *testing.T) {
+	cfg := testStreamConfig()
+	cfg.Cooldown = 10 * time.Second
+	e := NewStreamEngine(cfg)
+	// A healthy baseline, then a minute of mostly failing calls.
+	for s := 0; s < 240; s += 2 {
+		e.Observe(streamAt(time.Duration(s)*time.Second, 200, 100, 0.01))
+	}
+	for s := 240; s < 300; s += 2 {
+		e.Observe(streamAt(time.Duration(s)*time.Second, 503, 100, 0.01))
+	}
+
+	fired := e.Evaluate(streamStart.Add(5 * time.Minute))
+	if len(fired) != 1 || fired[0].Type != "error_surge" || fired[0].OrganizationID != 1 {
+		t.Fatalf("Evaluate() = %+v, want one error_surge for org 1", fired)
+	}
+
+	// The surge is still in the short window one bucket later, but the
+	// alert is in cooldown; once that passes it fires again.
+	if fired := e.Evaluate(streamStart.Add(5*time.Minute + 5*time.Second)); len(fired) != 0 {
+		t.Errorf("Evaluate() during cooldown = %+v, want none", fired)
+	}
+	fired = e.Evaluate(streamStart.Add(5*time.Minute + 10*time.Second))
+	if len(fired) != 1 || fired[0].Type != "error_surge" {
+		t.Errorf("Evaluate() after cooldown = %+v, want error_surge again", fired)
+	}
+
+	// With no traffic for a full window the key is dropped.
+	e.Evaluate(streamStart.Add(20 * time.Minute))
+	if len(e.windows) != 0 {
+		t.Errorf("%d idle windows kept, want 0", len(e.windows))
+	}
+}
+
+func TestCheckWindow(t *testing.T) {
+	e := NewStreamEngine(testStreamConfig())
+	key := streamKey{orgID: 7, provider: "OpenAI"}
+	agg := func(requests, errors int, cost, seconds float64, latencyMS int) windowAggregate {
+		a := windowAggregate{Requests: requests, Errors: errors, Cost: cost, Seconds: seconds}
+		for i := 0; i < requests; i++ {
+			a.latency.add(latencyMS)
+		}
+		a.P95Latency = a.latency.quantile(0.95)
+		return a
+	}
+	baseline := agg(200, 0, 2, 240, 100)
+
+	tests := []struct {
+		name  string
+		short windowAggregate
+		long  windowAggregate
+		want  []string
+	}{
+		{"quiet", agg(30, 0, 0.25, 60, 100), baseline, nil},
+		{"too few requests", agg(19, 19, 10, 60, 1000), baseline, nil},
+		{"error ratio at threshold", agg(20, 4, 0.2, 60, 100), baseline, []string{"error_surge"}},
+		{"error ratio below threshold", agg(20, 3, 0.2, 60, 100), baseline, nil},
+		{"error ratio not double the baseline", agg(20, 6, 0.2, 60, 100), agg(200, 40, 2, 240, 100), nil},
+		{"errors without a baseline", agg(20, 10, 0.2, 60, 100), agg(5, 0, 0, 240, 100), []string{"error_surge"}},
+		{"cost needs a baseline", agg(20, 0, 5, 60, 100), agg(5, 0, 0.01, 240, 100), nil},
+		{"cost spike", agg(30, 0, 1.5, 60, 100), baseline, []string{"live_cost_spike"}},
+		{"latency regression", agg(30, 0, 0.25, 60, 250), baseline, []string{"latency_regression"}},
+	}
+	for _, tt := range tests {
+		got := e.checkWindow(key, tt.short, tt.long, streamStart)
+		types := []string{}
+		for _, a := range got {
+			types = append(types, a.Type)
+			if a.OrganizationID != 7 || a.Provider != "OpenAI" {
+				t.Errorf("%s: anomaly for org %d %s", tt.name, a.OrganizationID, a.Provider)
+			}
+		}
+		if len(types) != len(tt.want) {
+			t.Errorf("%s: checkWindow() = %v, want %v", tt.name, types, tt.want)
+			continue
+		}
+		for i := range types {
+			if types[i] != tt.want[i] {
+				t.Errorf("%s: checkWindow() = %v, want %v", tt.name, types, tt.want)
+			}
+		}
+	}
+}
+
+func TestStreamEngineDropsUnknownOrganizations(t *testing.T) {
+	e := NewStreamEngine(testStreamConfig())
+	for _, org := range []string{"", "acme"} {
+		ev := streamAt(0, 500, 100, 0.01)
+		ev.OrganizationID = org
+		e.Observe(ev)
+	}
+	if len(e.windows) != 0 {
+		t.Errorf("%d windows for events without a valid organization, want 0", len(e.windows))
+	}
+}
+
+func TestBackfillFilter(t *testing.T) {
+	until := streamStart.Add(time.Minute)
+	filter := func() *backfillFilter {
+		return &backfillFilter{until: until.UnixMilli(), seen: map[string]bool{"a": true}, expires: until.Add(streamBackfillOverlap)}
+	}
+	event := func(id string, at time.Duration) streamEvent {
+		ev := streamAt(at, 200, 100, 0.01)
+		ev.RequestID = id
+		return ev
+	}
+
+	tests := []struct {
+		name   string
+		filter *backfillFilter
+		event  streamEvent
+		now    time.Time
+		skip   bool
+	}{
+		{"read by the backfill", filter(), event("a", 30*time.Second), until, true},
+		{"stamped before the bound but committed after the snapshot", filter(), event("b", 30*time.Second), until, false},
+		{"after the bound", filter(), event("c", 2*time.Minute), until, false},
+		{"without a request id", filter(), event("", 30*time.Second), until, true},
+		{"after the overlap", filter(), event("a", 30*time.Second), until.Add(2 * streamBackfillOverlap), false},
+		{"backfill failed", &backfillFilter{}, event("a", -time.Hour), until, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.skip(tt.event, tt.now); got != tt.skip {
+				t.Errorf("skip() = %v, want %v", got, tt.skip)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const apiKeyCacheTTL = time.Minute
+
+type orgContextKey struct{}
+
+type cachedOrg struct {
+	id       int
+	loadedAt time.Time
+}
+
+// apiKeys resolves API keys to organization ids, caching hits for
+// apiKeyCacheTTL. Unknown keys are never cached, so the cache holds at most
+// one entry per valid key however many invalid keys callers send.
+type apiKeys struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	cache map[string]cachedOrg
+}
+
+func newAPIKeys(db *sql.DB) *apiKeys {
+	return &apiKeys{db: db, cache: make(map[string]cachedOrg)}
+}
+
+func (k *apiKeys) organization(ctx context.Context, key string) (int, error) {
+	k.mu.Lock()
+	cached, ok := k.cache[key]
+	k.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < apiKeyCacheTTL {
+		return cached.id, nil
+	}
+
+	org := cachedOrg{loadedAt: time.Now()}
+	err := k.db.QueryRowContext(ctx, `SELECT id FROM organizations WHERE api_key = $1`, key).Scan(&org.id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	k.mu.Lock()
+	if err == sql.ErrNoRows {
+		// Drops a key that was revoked since it was cached
+		delete(k.cache, key)
+	} else {
+		k.cache[key] = org
+	}
+	k.mu.Unlock()
+	return org.id, nil
+}
+
+// requestAPIKey reads the key from X-API-Key or a bearer token. Browsers
+// cannot set headers on a WebSocket, so /ws also accepts an api_key query
+// parameter.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if r.URL.Path == "/ws" {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// authMiddleware resolves the caller's organization for every /api and /ws
+// request and rejects requests without a valid API key.
+func (g *Gateway) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" && !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := requestAPIKey(r)
+		if key == "" {
+			http.Error(w, "Missing API key", http.StatusUnauthorized)
+			return
+		}
+		orgID, err := g.keys.organization(r.Context(), key)
+		if err != nil {
+			log.Printf("API key lookup failed: %v", err)
+			http.Error(w, "Authentication unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if orgID == 0 {
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), orgContextKey{}, orgID)))
+	})
+}
+
+// requestOrg returns the organization authenticated by authMiddleware.
+func requestOrg(r *http.Request) int {
+	orgID, _ := r.Context().Value(orgContextKey{}).(int)
+	return orgID
+}
+
+// organizationParam returns the caller's organization id for RPCs that take
+// one. An organization_id that names a different organization is rejected.
+func organizationParam(w http.ResponseWriter, r *http.Request, requested string) (string, bool) {
+	orgID := strconv.Itoa(requestOrg(r))
+	if requested != "" && requested != orgID {
+		http.Error(w, "Forbidden organization", http.StatusForbidden)
+		return "", false
+	}
+	return orgID, true
+}
+
+// eventVisibleTo reports whether an api_events message may be forwarded to
+// a WebSocket client of orgID. Messages without an organization are never
+// forwarded.
+func eventVisibleTo(event map[string]interface{}, orgID int) bool {
+	id, ok := eventOrganization(event)
+	return ok && id == orgID
+}
+
+// eventOrganization extracts the organization an api_events message belongs
+// to, from organization_id or a nested anomaly.
+func eventOrganization(event map[string]interface{}) (int, bool) {
+	value, ok := event["organization_id"]
+	if !ok {
+		if anomaly, isMap := event["anomaly"].(map[string]interface{}); isMap {
+			value, ok = anomaly["organization_id"]
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		id, err := strconv.Atoi(v)
+		return id, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const keyQuery = `SELECT id FROM organizations WHERE api_key = \$1`
+
+// newAuthGateway returns a gateway whose API key lookups go to mock, and a
+// handler behind its auth middleware that echoes the caller's organization.
+func newAuthGateway(t *testing.T) (*Gateway, http.Handler, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	g := &Gateway{db: db, keys: newAPIKeys(db)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/costs", func(w http.ResponseWriter, r *http.Request) {
+		orgID, ok := organizationParam(w, r, r.URL.Query().Get("organization_id"))
+		if !ok {
+			return
+		}
+		fmt.Fprint(w, orgID)
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, requestOrg(r))
+	})
+	return g, g.authMiddleware(mux), mock
+}
+
+func serve(h http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	_, h, mock := newAuthGateway(t)
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery(keyQuery).WithArgs("good").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(keyQuery).WithArgs("bad").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{"missing key", "/api/costs", nil, http.StatusUnauthorized, ""},
+		{"invalid key", "/api/costs", map[string]string{"X-API-Key": "bad"}, http.StatusUnauthorized, ""},
+		{"valid key", "/api/costs", map[string]string{"X-API-Key": "good"}, http.StatusOK, "3"},
+		{"bearer token", "/api/costs", map[string]string{"Authorization": "Bearer good"}, http.StatusOK, "3"},
+		{"own organization", "/api/costs?organization_id=3", map[string]string{"X-API-Key": "good"}, http.StatusOK, "3"},
+		{"other organization", "/api/costs?organization_id=4", map[string]string{"X-API-Key": "good"}, http.StatusForbidden, ""},
+		{"query key on the WebSocket", "/ws?api_key=good", nil, http.StatusOK, "3"},
+		{"query key elsewhere", "/api/costs?api_key=good", nil, http.StatusUnauthorized, ""},
+		{"public route", "/health", nil, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.path, tt.headers)
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.code, rec.Body.String())
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("organization = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+
+	// Each key is looked up once; later requests are served from the cache
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAPIKeyCache(t *testing.T) {
+	g, h, mock := newAuthGateway(t)
+	headers := map[string]string{"X-API-Key": "revoked"}
+	expire := func() {
+		g.keys.mu.Lock()
+		defer g.keys.mu.Unlock()
+		for key, org := range g.keys.cache {
+			org.loadedAt = org.loadedAt.Add(-apiKeyCacheTTL - time.Second)
+			g.keys.cache[key] = org
+		}
+	}
+
+	mock.ExpectQuery(keyQuery).WithArgs("revoked").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	if rec := serve(h, "/api/costs", headers); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d before revocation, want 200", rec.Code)
+	}
+
+	// Revoked in the database, but still cached
+	if rec := serve(h, "/api/costs", headers); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d within the cache TTL, want 200", rec.Code)
+	}
+
+	expire()
+	mock.ExpectQuery(keyQuery).WithArgs("revoked").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if rec := serve(h, "/api/costs", headers); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d after the cache expired, want 401", rec.Code)
+	}
+	if _, cached := g.keys.cache["revoked"]; cached {
+		t.Error("revoked key still cached")
+	}
+
+	// Unknown keys are looked up every time rather than cached
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("random-%d", i)
+		mock.ExpectQuery(keyQuery).WithArgs(key).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		serve(h, "/api/costs", map[string]string{"X-API-Key": key})
+	}
+	if len(g.keys.cache) != 0 {
+		t.Errorf("%d keys cached after invalid lookups, want 0", len(g.keys.cache))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEventVisibleTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   map[string]interface{}
+		visible bool
+	}{
+		{"own request", map[string]interface{}{"type": "new_request", "organization_id": "3"}, true},
+		{"numeric organization", map[string]interface{}{"type": "new_request", "organization_id": float64(3)}, true},
+		{"own anomaly", map[string]interface{}{"type": "anomaly", "anomaly": map[string]interface{}{"organization_id": float64(3)}}, true},
+		{"other organization's request", map[string]interface{}{"type": "new_request", "organization_id": "4"}, false},
+		{"other organization's anomaly", map[string]interface{}{"type": "anomaly", "anomaly": map[string]interface{}{"organization_id": float64(4)}}, false},
+		{"no organization", map[string]interface{}{"type": "new_request"}, false},
+		{"unparseable organization", map[string]interface{}{"type": "new_request", "organization_id": "acme"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventVisibleTo(tt.event, 3); got != tt.visible {
+				t.Errorf("eventVisibleTo() = %v, want %v", got, tt.visible)
+			}
+		})
+	}
+}
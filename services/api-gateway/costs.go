@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/yourusername/api-observatory/api-gateway/proto"
+	"github.com/yourusername/api-observatory/shared/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// parseTimeParam accepts Unix milliseconds or RFC3339 and returns Unix
+// milliseconds, or 0 when the parameter is absent.
+func parseTimeParam(value string) (int64, bool) {
+	if value == "" {
+		return 0, true
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ms, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), true
+	}
+	return 0, false
+}
+
+// handleGetCostBreakdown proxies GET /api/costs/breakdown to the
+// cost-tracker's GetCostBreakdown RPC.
+// Query: start, end (Unix ms or RFC3339), group_by.
+func (g *Gateway) handleGetCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	start, ok := parseTimeParam(q.Get("start"))
+	if !ok {
+		http.Error(w, "Invalid start", http.StatusBadRequest)
+		return
+	}
+	end, ok := parseTimeParam(q.Get("end"))
+	if !ok {
+		http.Error(w, "Invalid end", http.StatusBadRequest)
+		return
+	}
+	orgID, ok := organizationParam(w, r, q.Get("organization_id"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	resp, err := g.costs.GetCostBreakdown(ctx, &pb.CostRequest{
+		OrganizationId: orgID,
+		StartTime:      start,
+		EndTime:        end,
+		GroupBy:        q.Get("group_by"),
+	})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("GetCostBreakdown failed: %v", err)
+		http.Error(w, "Cost tracker unavailable", http.StatusBadGateway)
+		return
+	}
+
+	data, err := protoJSON.Marshal(resp)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleBudgets lists budget status on GET and creates or updates a budget
+// through the cost-tracker's SetBudgetAlert RPC on POST. The POST body uses
+// the BudgetAlertRequest fields, e.g.
+// {"monthly_budget": 500, "provider": "OpenAI"}.
+func (g *Gateway) handleBudgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		data, err := g.redis.Get(r.Context(), tenant.Key("costs:budgets", requestOrg(r))).Result()
+		if err != nil {
+			data = "[]"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(data))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var req pb.BudgetAlertRequest
+	if err := protojson.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	orgID, ok := organizationParam(w, r, req.OrganizationId)
+	if !ok {
+		return
+	}
+	req.OrganizationId = orgID
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := g.costs.SetBudgetAlert(ctx, &req)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("SetBudgetAlert failed: %v", err)
+		http.Error(w, "Cost tracker unavailable", http.StatusBadGateway)
+		return
+	}
+
+	data, _ := protoJSON.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleGetProviderComparison proxies GET /api/costs/comparison to the
+// cost-tracker's GetProviderComparison RPC.
+// Query: current_provider, alternatives (comma-separated
+// "Provider" or "Provider/Plan"), start, end.
+func (g *Gateway) handleGetProviderComparison(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	start, ok := parseTimeParam(q.Get("start"))
+	if !ok {
+		http.Error(w, "Invalid start", http.StatusBadRequest)
+		return
+	}
+	end, ok := parseTimeParam(q.Get("end"))
+	if !ok {
+		http.Error(w, "Invalid end", http.StatusBadRequest)
+		return
+	}
+
+	alternatives := []string{}
+	for _, name := range strings.Split(q.Get("alternatives"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			alternatives = append(alternatives, name)
+		}
+	}
+	orgID, ok := organizationParam(w, r, q.Get("organization_id"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	resp, err := g.costs.GetProviderComparison(ctx, &pb.ComparisonRequest{
+		OrganizationId:       orgID,
+		CurrentProvider:      q.Get("current_provider"),
+		AlternativeProviders: alternatives,
+		StartTime:            start,
+		EndTime:              end,
+	})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("GetProviderComparison failed: %v", err)
+		http.Error(w, "Cost tracker unavailable", http.StatusBadGateway)
+		return
+	}
+
+	data, _ := protoJSON.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// handleGetChargeback proxies GET /api/costs/chargeback to the cost-tracker's
+// GetChargeback RPC. Query: key (e.g. team), month (YYYY-MM).
+func (g *Gateway) handleGetChargeback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	orgID, ok := organizationParam(w, r, q.Get("organization_id"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	resp, err := g.costs.GetChargeback(ctx, &pb.ChargebackRequest{
+		OrganizationId: orgID,
+		TagKey:         q.Get("key"),
+		Month:          q.Get("month"),
+	})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("GetChargeback failed: %v", err)
+		http.Error(w, "Cost tracker unavailable", http.StatusBadGateway)
+		return
+	}
+
+	data, _ := protoJSON.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// maxInvoiceUpload matches the cost-tracker's gRPC receive limit.
+const maxInvoiceUpload = 32 << 20
+
+// handleReconcileInvoice proxies POST /api/costs/reconcile to the
+// cost-tracker's ReconcileInvoice RPC. The CSV is the request body or a
+// multipart "file" field. Query: provider, tolerance (%).
+func (g *Gateway) handleReconcileInvoice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	orgID, ok := organizationParam(w, r, q.Get("organization_id"))
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxInvoiceUpload)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	var tolerance float64
+	if value := q.Get("tolerance"); value != "" {
+		if tolerance, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "Invalid tolerance", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	resp, err := g.costs.ReconcileInvoice(ctx, &pb.InvoiceRequest{
+		OrganizationId:   orgID,
+		Provider:         q.Get("provider"),
+		Csv:              data,
+		TolerancePercent: tolerance,
+	}, grpc.MaxCallSendMsgSize(maxInvoiceUpload))
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("ReconcileInvoice failed: %v", err)
+		http.Error(w, "Cost tracker unavailable", http.StatusBadGateway)
+		return
+	}
+
+	out, _ := protoJSON.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
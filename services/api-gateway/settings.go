@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Column defaults of anomaly_settings, for organizations without a row.
+const (
+	defaultSpikeSensitivity = 3.5
+	defaultSpikeScaleFloor  = 0.1
+)
+
+type AnomalySettings struct {
+	CostSpikeSensitivity float64 `json:"cost_spike_sensitivity"`
+	CostSpikeScaleFloor  float64 `json:"cost_spike_scale_floor"`
+}
+
+// handleAnomalySettings returns the caller's cost-spike settings on GET and
+// updates them on POST. Fields left out of the POST body keep their current
+// value, e.g. {"cost_spike_sensitivity": 4}. The analytics service reads the
+// settings on its next cost_spikes run.
+func (g *Gateway) handleAnomalySettings(w http.ResponseWriter, r *http.Request) {
+	orgID := requestOrg(r)
+
+	settings := AnomalySettings{CostSpikeSensitivity: defaultSpikeSensitivity, CostSpikeScaleFloor: defaultSpikeScaleFloor}
+	err := g.db.QueryRowContext(r.Context(), `
+        SELECT cost_spike_sensitivity, cost_spike_scale_floor
+        FROM anomaly_settings
+        WHERE organization_id = $1
+    `, orgID).Scan(&settings.CostSpikeSensitivity, &settings.CostSpikeScaleFloor)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Failed to load anomaly settings: %v", err)
+		http.Error(w, "Failed to load anomaly settings", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil || json.Unmarshal(body, &settings) != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if settings.CostSpikeSensitivity <= 0 || settings.CostSpikeSensitivity >= 1000 {
+			http.Error(w, "cost_spike_sensitivity must be between 0 and 1000", http.StatusBadRequest)
+			return
+		}
+		if settings.CostSpikeScaleFloor < 0 || settings.CostSpikeScaleFloor > 1 {
+			http.Error(w, "cost_spike_scale_floor must be between 0 and 1", http.StatusBadRequest)
+			return
+		}
+
+		_, err = g.db.ExecContext(r.Context(), `
+            INSERT INTO anomaly_settings (organization_id, cost_spike_sensitivity, cost_spike_scale_floor)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (organization_id) DO UPDATE SET
+                cost_spike_sensitivity = EXCLUDED.cost_spike_sensitivity,
+                cost_spike_scale_floor = EXCLUDED.cost_spike_scale_floor,
+                updated_at = NOW()
+        `, orgID, settings.CostSpikeSensitivity, settings.CostSpikeScaleFloor)
+		if err != nil {
+			log.Printf("Failed to save anomaly settings: %v", err)
+			http.Error(w, "Failed to save anomaly settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/yourusername/api-observatory/cost-tracker/proto"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultAllocationKeys = "team,service,feature,customer_id"
+	// Requests without the tag, or with an empty value, are grouped under
+	// untaggedValue. No real tag value is empty, so it cannot collide.
+	untaggedValue = ""
+	// tagCostsCursorKey holds, per allocation key, the hour up to which
+	// tag_costs_hourly has been aggregated, and tagCostsStartKey the hour it
+	// has been aggregated from.
+	tagCostsCursorKey = "costs:tag_costs:cursor:"
+	tagCostsStartKey  = "costs:tag_costs:start:"
+	// Hours before the cursor that are re-aggregated on each run to pick up
+	// late requests.
+	tagCostsLateness = time.Hour
+)
+
+type allocationRule struct {
+	source  string // tag value whose cost is reallocated; untaggedValue for untagged
+	method  string
+	targets map[string]float64
+}
+
+// allocationKeys returns the metadata keys chargeback reports are built for.
+func allocationKeys() []string {
+	value := os.Getenv("ALLOCATION_KEYS")
+	if value == "" {
+		value = defaultAllocationKeys
+	}
+	keys := []string{}
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); tagKeyPattern.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *CostTrackerServer) loadAllocationRules(ctx context.Context, orgID int, key string) ([]allocationRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT source_value, method, targets
+        FROM allocation_rules
+        WHERE organization_id = $1 AND tag_key = $2
+    `, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []allocationRule{}
+	for rows.Next() {
+		var source sql.NullString
+		var targets []byte
+		r := allocationRule{source: untaggedValue}
+		if err := rows.Scan(&source, &r.method, &targets); err != nil {
+			continue
+		}
+		if source.Valid {
+			r.source = source.String
+		}
+		if err := json.Unmarshal(targets, &r.targets); err != nil {
+			log.Printf("Ignoring allocation rule for %s=%s with invalid targets: %v", key, r.source, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// chargeback splits an organization's spend by the values of one metadata
+// key, then applies its allocation rules.
+func (s *CostTrackerServer) chargeback(ctx context.Context, orgID int, key string, start, end time.Time) (*pb.ChargebackResponse, error) {
+	direct, err := s.tagCosts(ctx, orgID, key, start, end)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.loadAllocationRules(ctx, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	return allocateCosts(key, start, end, direct, rules), nil
+}
+
+// allocateCosts builds a chargeback report from direct cost per tag value,
+// moving each rule's source cost onto other values. Costs are moved from
+// direct spend only, so rules never cascade.
+func allocateCosts(key string, start, end time.Time, direct []CostBreakdown, rules []allocationRule) *pb.ChargebackResponse {
+	lines := map[string]*pb.ChargebackLine{}
+	line := func(value string) *pb.ChargebackLine {
+		l, ok := lines[value]
+		if !ok {
+			l = &pb.ChargebackLine{Value: value, Untagged: value == untaggedValue}
+			lines[value] = l
+		}
+		return l
+	}
+	for _, item := range direct {
+		l := line(item.Label)
+		l.DirectCost = item.Cost
+		l.RequestCount = item.RequestCount
+	}
+
+	sources := map[string]bool{}
+	for _, r := range rules {
+		sources[r.source] = true
+	}
+
+	for _, r := range rules {
+		src, ok := lines[r.source]
+		if !ok || src.DirectCost == 0 {
+			continue
+		}
+
+		// Weights are fixed shares, or each target's direct cost
+		weights := map[string]float64{}
+		switch r.method {
+		case "fixed":
+			for value, w := range r.targets {
+				if w > 0 && !sources[value] {
+					weights[value] = w
+				}
+			}
+		default:
+			for value, l := range lines {
+				if sources[value] || value == untaggedValue {
+					continue
+				}
+				if _, listed := r.targets[value]; len(r.targets) > 0 && !listed {
+					continue
+				}
+				weights[value] = l.DirectCost
+			}
+		}
+
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		if total == 0 {
+			continue
+		}
+
+		amount := src.DirectCost
+		for value, w := range weights {
+			line(value).AllocatedCost += amount * w / total
+		}
+		src.AllocatedCost -= amount
+	}
+
+	resp := &pb.ChargebackResponse{
+		TagKey:      key,
+		PeriodStart: start.UnixMilli(),
+		PeriodEnd:   end.UnixMilli(),
+	}
+	for _, l := range lines {
+		l.TotalCost = l.DirectCost + l.AllocatedCost
+		resp.TotalCost += l.TotalCost
+		if l.Value == untaggedValue || sources[l.Value] {
+			resp.UnallocatedCost += l.TotalCost
+		}
+		resp.Lines = append(resp.Lines, l)
+	}
+	for _, l := range resp.Lines {
+		if resp.TotalCost > 0 {
+			l.Share = 100 * l.TotalCost / resp.TotalCost
+		}
+	}
+	sort.Slice(resp.Lines, func(i, j int) bool {
+		if resp.Lines[i].TotalCost != resp.Lines[j].TotalCost {
+			return resp.Lines[i].TotalCost > resp.Lines[j].TotalCost
+		}
+		return resp.Lines[i].Value < resp.Lines[j].Value
+	})
+	return resp
+}
+
+func monthRange(month string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month != "" {
+		t, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = t
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// aggregateTagCosts materializes hourly cost per value of key into
+// tag_costs_hourly for [from, to). Hours are recomputed in full, so ranges
+// may overlap earlier runs.
+func (s *CostTrackerServer) aggregateTagCosts(ctx context.Context, key string, from, to time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO tag_costs_hourly (organization_id, tag_key, tag_value, bucket, request_count, total_cost)
+        SELECT
+            organization_id,
+            $1,
+            COALESCE(metadata->>$1, ''),
+            time_bucket('1 hour', time),
+            COUNT(*),
+            COALESCE(SUM(cost), 0)
+        FROM api_requests
+        WHERE time >= $2 AND time < $3
+        GROUP BY organization_id, 3, 4
+        ON CONFLICT (organization_id, tag_key, tag_value, bucket) DO UPDATE SET
+            request_count = EXCLUDED.request_count,
+            total_cost = EXCLUDED.total_cost
+    `, key, from, to)
+	return err
+}
+
+// tagCostsCursor returns the hour tag_costs_hourly is complete up to for
+// key, or false if it has never been aggregated.
+func (s *CostTrackerServer) tagCostsCursor(ctx context.Context, key string) (time.Time, bool) {
+	unix, err := s.redis.Get(ctx, tagCostsCursorKey+key).Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0).UTC(), true
+}
+
+// tagCostsRange returns the hours [from, to) tag_costs_hourly holds for key,
+// or false if it holds none.
+func (s *CostTrackerServer) tagCostsRange(ctx context.Context, key string) (time.Time, time.Time, bool) {
+	to, ok := s.tagCostsCursor(ctx, key)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	unix, err := s.redis.Get(ctx, tagCostsStartKey+key).Int64()
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(unix, 0).UTC(), to, true
+}
+
+// updateTagCosts extends tag_costs_hourly for key to the last whole hour.
+// The first run starts at the beginning of last month, the oldest month a
+// chargeback is recomputed for, and records that as the start of the
+// materialized hours.
+func (s *CostTrackerServer) updateTagCosts(ctx context.Context, key string, now time.Time) error {
+	to := now.UTC().Truncate(time.Hour)
+	current, _, _ := monthRange("", now)
+	from := current.AddDate(0, -1, 0)
+	if cursor, ok := s.tagCostsCursor(ctx, key); ok && cursor.Add(-tagCostsLateness).After(from) {
+		from = cursor.Add(-tagCostsLateness)
+	}
+	if !from.Before(to) {
+		return nil
+	}
+
+	if err := s.aggregateTagCosts(ctx, key, from, to); err != nil {
+		return err
+	}
+	// Only the first recorded start stands; hours from it onwards stay
+	// materialized.
+	if err := s.redis.SetNX(ctx, tagCostsStartKey+key, from.Unix(), 0).Err(); err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, tagCostsCursorKey+key, to.Unix(), 0).Err()
+}
+
+// materializedSpan returns the part of [start, end) inside the materialized
+// hours [low, high). The span is empty, from == to == start, when they
+// don't overlap.
+func materializedSpan(start, end, low, high time.Time) (time.Time, time.Time) {
+	from, to := start, end
+	if low.After(from) {
+		from = low
+	}
+	if high.Before(to) {
+		to = high
+	}
+	if !from.Before(to) {
+		return start, start
+	}
+	return from, to
+}
+
+// tagCosts returns an organization's cost per value of key over
+// [start, end): hours tag_costs_hourly holds from there, the rest from raw
+// rows.
+func (s *CostTrackerServer) tagCosts(ctx context.Context, orgID int, key string, start, end time.Time) ([]CostBreakdown, error) {
+	from, to := start, start
+	if low, high, ok := s.tagCostsRange(ctx, key); ok {
+		from, to = materializedSpan(start, end, low, high)
+	}
+
+	totals := map[string]*CostBreakdown{}
+	add := func(rows *sql.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var item CostBreakdown
+			if err := rows.Scan(&item.Label, &item.RequestCount, &item.Cost); err != nil {
+				continue
+			}
+			t, ok := totals[item.Label]
+			if !ok {
+				t = &CostBreakdown{Label: item.Label, Untagged: item.Label == untaggedValue}
+				totals[item.Label] = t
+			}
+			t.RequestCount += item.RequestCount
+			t.Cost += item.Cost
+		}
+		return rows.Err()
+	}
+
+	if to.After(from) {
+		rows, err := s.db.QueryContext(ctx, `
+            SELECT tag_value, COALESCE(SUM(request_count), 0), COALESCE(SUM(total_cost), 0)
+            FROM tag_costs_hourly
+            WHERE organization_id = $1 AND tag_key = $2 AND bucket >= $3 AND bucket < $4
+            GROUP BY tag_value
+        `, orgID, key, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(rows); err != nil {
+			return nil, err
+		}
+	}
+	for _, raw := range [][2]time.Time{{start, from}, {to, end}} {
+		if !raw[1].After(raw[0]) {
+			continue
+		}
+		rows, err := s.db.QueryContext(ctx, `
+            SELECT COALESCE(metadata->>$2, ''), COUNT(*), COALESCE(SUM(cost), 0)
+            FROM api_requests
+            WHERE organization_id = $1 AND time >= $3 AND time < $4
+            GROUP BY 1
+        `, orgID, key, raw[0], raw[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := add(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	breakdown := make([]CostBreakdown, 0, len(totals))
+	for _, t := range totals {
+		breakdown = append(breakdown, *t)
+	}
+	return breakdown, nil
+}
+
+// refreshTagCosts recomputes tag_costs_hourly after raw costs between from
+// and to changed, and drops the stored reports of the affected months.
+func (s *CostTrackerServer) refreshTagCosts(ctx context.Context, from, to time.Time) {
+	for _, key := range allocationKeys() {
+		low, high, ok := s.tagCostsRange(ctx, key)
+		if !ok {
+			continue
+		}
+		start, end := materializedSpan(from.UTC().Truncate(time.Hour), to, low, high)
+		if !start.Before(end) {
+			continue
+		}
+		if err := s.aggregateTagCosts(ctx, key, start, end); err != nil {
+			log.Printf("Failed to refresh tag costs for %s: %v", key, err)
+		}
+	}
+
+	first, _, _ := monthRange("", from)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM chargeback_reports WHERE month >= $1 AND month <= $2`,
+		first.Format("2006-01-02"), to.UTC().Format("2006-01-02")); err != nil {
+		log.Printf("Failed to drop stale chargeback reports: %v", err)
+	}
+}
+
+// monthFinal reports whether a month's costs can no longer change: it has
+// ended and the pricing true-up grace period has passed.
+func monthFinal(month, now time.Time) bool {
+	return !now.Before(month.AddDate(0, 1, 0).Add(pricingGracePeriod))
+}
+
+// monthlyChargeback returns a month's chargeback report. Final months are
+// computed once and stored in chargeback_reports.
+func (s *CostTrackerServer) monthlyChargeback(ctx context.Context, orgID int, key string, month, now time.Time) (*pb.ChargebackResponse, bool, error) {
+	final := monthFinal(month, now)
+	if final {
+		var data []byte
+		err := s.db.QueryRowContext(ctx, `
+            SELECT report FROM chargeback_reports
+            WHERE organization_id = $1 AND tag_key = $2 AND month = $3
+        `, orgID, key, month.Format("2006-01-02")).Scan(&data)
+		if err == nil {
+			report := &pb.ChargebackResponse{}
+			if err := protojson.Unmarshal(data, report); err == nil {
+				return report, true, nil
+			}
+		} else if err != sql.ErrNoRows {
+			return nil, false, err
+		}
+	}
+
+	report, err := s.chargeback(ctx, orgID, key, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, false, err
+	}
+	if final {
+		data, err := protojson.Marshal(report)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, err := s.db.ExecContext(ctx, `
+            INSERT INTO chargeback_reports (organization_id, tag_key, month, report)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (organization_id, tag_key, month) DO UPDATE SET
+                report = EXCLUDED.report,
+                computed_at = NOW()
+        `, orgID, key, month.Format("2006-01-02"), data); err != nil {
+			log.Printf("Failed to store chargeback for org %d by %s: %v", orgID, key, err)
+		}
+	}
+	return report, final, nil
+}
+
+// GetChargeback returns a month's chargeback report for one allocation key.
+func (c *costService) GetChargeback(ctx context.Context, req *pb.ChargebackRequest) (*pb.ChargebackResponse, error) {
+	orgID, err := strconv.Atoi(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid organization_id %q", req.OrganizationId)
+	}
+	if !tagKeyPattern.MatchString(req.TagKey) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tag_key %q", req.TagKey)
+	}
+	now := time.Now()
+	month, _, err := monthRange(req.Month, now)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid month %q, expected YYYY-MM", req.Month)
+	}
+
+	resp, _, err := c.server.monthlyChargeback(ctx, orgID, req.TagKey, month, now)
+	if err != nil {
+		log.Printf("Chargeback for org %d by %s failed: %v", orgID, req.TagKey, err)
+		return nil, status.Error(codes.Internal, "failed to compute chargeback")
+	}
+	return resp, nil
+}
+
+func (s *CostTrackerServer) trackChargeback() {
+	interval := jobs.EnvDuration("CHARGEBACK_INTERVAL", time.Hour)
+	s.jobs.Every("chargeback", interval, interval, s.calculateChargeback)
+}
+
+// calculateChargeback extends the hourly tag totals, then publishes last
+// month's and this month's chargeback reports for every organization and
+// allocation key. Only months that are not yet final are recomputed.
+func (s *CostTrackerServer) calculateChargeback(ctx context.Context) error {
+	now := time.Now()
+	keys := allocationKeys()
+	for _, key := range keys {
+		if err := s.updateTagCosts(ctx, key, now); err != nil {
+			return fmt.Errorf("aggregate tag costs for %s: %w", key, err)
+		}
+	}
+
+	orgIDs, err := s.orgs.OrganizationIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, _, _ := monthRange("", now)
+	months := []time.Time{current.AddDate(0, -1, 0), current}
+
+	marshal := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	byOrg := map[int]interface{}{}
+	for _, orgID := range orgIDs {
+		reports := []map[string]interface{}{}
+		for _, key := range keys {
+			for _, month := range months {
+				report, final, err := s.monthlyChargeback(ctx, orgID, key, month, now)
+				if err != nil {
+					return err
+				}
+				data, _ := marshal.Marshal(report)
+				reports = append(reports, map[string]interface{}{
+					"organization_id": orgID,
+					"month":           month.Format("2006-01"),
+					"final":           final,
+					"report":          json.RawMessage(data),
+				})
+			}
+		}
+		byOrg[orgID] = reports
+	}
+
+	return s.orgs.Publish(ctx, "costs:chargeback", byOrg, []interface{}{}, 3*jobs.EnvDuration("CHARGEBACK_INTERVAL", time.Hour))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAllocateCosts(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	direct := []CostBreakdown{
+		{Label: "search", Cost: 60, RequestCount: 600},
+		{Label: "billing", Cost: 20, RequestCount: 200},
+		{Label: "platform", Cost: 10, RequestCount: 100},
+		{Label: untaggedValue, Cost: 10, RequestCount: 100, Untagged: true},
+	}
+
+	tests := []struct {
+		name        string
+		rules       []allocationRule
+		expected    map[string]float64 // total cost by value
+		unallocated float64
+	}{
+		{
+			"no rules",
+			nil,
+			map[string]float64{"search": 60, "billing": 20, "platform": 10, untaggedValue: 10},
+			10,
+		},
+		{
+			"fixed shares",
+			[]allocationRule{{source: "platform", method: "fixed", targets: map[string]float64{"search": 3, "billing": 1}}},
+			map[string]float64{"search": 67.5, "billing": 22.5, "platform": 0, untaggedValue: 10},
+			10,
+		},
+		{
+			"fixed share to a new value",
+			[]allocationRule{{source: "platform", method: "fixed", targets: map[string]float64{"infra": 1}}},
+			map[string]float64{"search": 60, "billing": 20, "platform": 0, "infra": 10, untaggedValue: 10},
+			10,
+		},
+		{
+			"proportional to direct cost",
+			[]allocationRule{{source: "platform", method: "proportional"}},
+			map[string]float64{"search": 67.5, "billing": 22.5, "platform": 0, untaggedValue: 10},
+			10,
+		},
+		{
+			"proportional among listed targets",
+			[]allocationRule{{source: "platform", method: "proportional", targets: map[string]float64{"billing": 1}}},
+			map[string]float64{"search": 60, "billing": 30, "platform": 0, untaggedValue: 10},
+			10,
+		},
+		{
+			"untagged source",
+			[]allocationRule{{source: untaggedValue, method: "proportional"}},
+			map[string]float64{"search": 60 + 60.0/9, "billing": 20 + 20.0/9, "platform": 10 + 10.0/9, untaggedValue: 0},
+			0,
+		},
+		{
+			"sources are never targets",
+			[]allocationRule{
+				{source: "platform", method: "proportional"},
+				{source: untaggedValue, method: "fixed", targets: map[string]float64{"platform": 1, "search": 1}},
+			},
+			map[string]float64{"search": 77.5, "billing": 22.5, "platform": 0, untaggedValue: 0},
+			0,
+		},
+		{
+			"rule without cost to move",
+			[]allocationRule{{source: "mobile", method: "proportional"}},
+			map[string]float64{"search": 60, "billing": 20, "platform": 10, untaggedValue: 10},
+			10,
+		},
+		{
+			"fixed rule with no valid target keeps its cost",
+			[]allocationRule{{source: "platform", method: "fixed", targets: map[string]float64{"platform": 1, "search": 0}}},
+			map[string]float64{"search": 60, "billing": 20, "platform": 10, untaggedValue: 10},
+			20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := allocateCosts("team", start, end, direct, tt.rules)
+			if math.Abs(report.TotalCost-100) > 1e-9 {
+				t.Errorf("TotalCost = %v, want 100", report.TotalCost)
+			}
+			if math.Abs(report.UnallocatedCost-tt.unallocated) > 1e-9 {
+				t.Errorf("UnallocatedCost = %v, want %v", report.UnallocatedCost, tt.unallocated)
+			}
+			if len(report.Lines) != len(tt.expected) {
+				t.Fatalf("got %d lines, want %d", len(report.Lines), len(tt.expected))
+			}
+			for i, l := range report.Lines {
+				want, ok := tt.expected[l.Value]
+				if !ok {
+					t.Fatalf("unexpected line %q", l.Value)
+				}
+				if math.Abs(l.TotalCost-want) > 1e-9 {
+					t.Errorf("%q total = %v, want %v", l.Value, l.TotalCost, want)
+				}
+				if math.Abs(l.TotalCost-l.DirectCost-l.AllocatedCost) > 1e-9 {
+					t.Errorf("%q total %v is not direct %v plus allocated %v", l.Value, l.TotalCost, l.DirectCost, l.AllocatedCost)
+				}
+				if l.Untagged != (l.Value == untaggedValue) {
+					t.Errorf("%q Untagged = %v", l.Value, l.Untagged)
+				}
+				if i > 0 && report.Lines[i-1].TotalCost < l.TotalCost {
+					t.Errorf("lines not sorted by total cost at %q", l.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestMaterializedSpan(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name             string
+		start, end       time.Time
+		low, high        time.Time
+		wantFrom, wantTo time.Time
+	}{
+		{"fully materialized", at(5), at(10), at(1), at(20), at(5), at(10)},
+		{"month before the first run", at(1), at(10), at(15), at(20), at(1), at(1)},
+		{"starts before the first run", at(1), at(20), at(5), at(25), at(5), at(20)},
+		{"ends after the cursor", at(5), at(20), at(1), at(10), at(5), at(10)},
+		{"materialized inside the range", at(1), at(30), at(5), at(10), at(5), at(10)},
+		{"after the cursor", at(15), at(20), at(1), at(10), at(15), at(15)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := materializedSpan(tt.start, tt.end, tt.low, tt.high)
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("materializedSpan() = [%s, %s), want [%s, %s)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
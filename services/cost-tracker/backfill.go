@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// CostBackfill is a cost recomputation job from the cost_backfills table.
+type CostBackfill struct {
+	ID             int        `json:"id"`
+	Provider       string     `json:"provider"`
+	OrganizationID *int       `json:"organization_id,omitempty"`
+	RangeStart     time.Time  `json:"range_start"`
+	RangeEnd       *time.Time `json:"range_end,omitempty"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	RowsUpdated    *int64     `json:"rows_updated,omitempty"` // omitted from other tenants' view of provider-wide backfills
+	Error          string     `json:"error,omitempty"`
+	RequestedAt    time.Time  `json:"requested_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// A running backfill records a heartbeat this often; one whose heartbeat is
+// older than backfillStaleAfter belongs to an instance that died and is
+// retried.
+const (
+	backfillHeartbeat  = 30 * time.Second
+	backfillStaleAfter = 4 * backfillHeartbeat
+)
+
+func (s *CostTrackerServer) trackBackfills() {
+	interval := jobs.EnvDuration("BACKFILL_INTERVAL", time.Minute)
+	s.jobs.Every("cost_backfills", interval, jobs.EnvDuration("BACKFILL_TIMEOUT", 30*time.Minute), s.runBackfills)
+}
+
+// runBackfills works through pending cost_backfills in request order. Each
+// claimed job carries this instance's name and a heartbeat, so only jobs
+// whose owner stopped reporting are taken back.
+func (s *CostTrackerServer) runBackfills(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `
+        UPDATE cost_backfills SET status = 'pending', claimed_by = NULL
+        WHERE status = 'running'
+          AND (heartbeat_at IS NULL OR heartbeat_at < NOW() - $1 * INTERVAL '1 second')
+    `, backfillStaleAfter.Seconds()); err != nil {
+		return err
+	}
+
+	for {
+		var b CostBackfill
+		var orgID sql.NullInt64
+		var end time.Time
+		err := s.db.QueryRowContext(ctx, `
+            UPDATE cost_backfills
+            SET status = 'running', started_at = NOW(), claimed_by = $1, heartbeat_at = NOW()
+            WHERE id = (
+                SELECT id FROM cost_backfills
+                WHERE status = 'pending'
+                ORDER BY requested_at
+                LIMIT 1
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING id, provider, organization_id, range_start, COALESCE(range_end, NOW())
+        `, s.jobs.Instance()).Scan(&b.ID, &b.Provider, &orgID, &b.RangeStart, &end)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		updated, err := s.claimedBackfill(ctx, b.ID, backfillHeartbeat, func(ctx context.Context) (int64, error) {
+			return s.runBackfill(ctx, b.Provider, orgID, b.RangeStart, end)
+		})
+		if err != nil {
+			log.Printf("Cost backfill %d for %s failed after %d rows: %v", b.ID, b.Provider, updated, err)
+			s.db.ExecContext(ctx, `
+                UPDATE cost_backfills SET status = 'failed', error = $2, rows_updated = $3, finished_at = NOW()
+                WHERE id = $1 AND claimed_by = $4
+            `, b.ID, err.Error(), updated, s.jobs.Instance())
+			continue
+		}
+
+		s.refreshAggregates(ctx, b.RangeStart, end)
+		if _, err := s.db.ExecContext(ctx, `
+            UPDATE cost_backfills SET status = 'done', error = NULL, rows_updated = $2, finished_at = NOW()
+            WHERE id = $1 AND claimed_by = $3
+        `, b.ID, updated, s.jobs.Instance()); err != nil {
+			return err
+		}
+		log.Printf("Cost backfill %d recomputed %d %s requests from %s to %s",
+			b.ID, updated, b.Provider, b.RangeStart.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	return s.publishBackfills(ctx)
+}
+
+// claimedBackfill runs fn while heartbeating the claimed backfill every
+// heartbeat. If the claim is lost, because another instance took the job
+// back, fn is cancelled.
+func (s *CostTrackerServer) claimedBackfill(ctx context.Context, id int, heartbeat time.Duration, fn func(ctx context.Context) (int64, error)) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			result, err := s.db.ExecContext(ctx, `
+                UPDATE cost_backfills SET heartbeat_at = NOW()
+                WHERE id = $1 AND status = 'running' AND claimed_by = $2
+            `, id, s.jobs.Instance())
+			if err != nil {
+				log.Printf("Cost backfill %d: failed to record heartbeat: %v", id, err)
+				continue
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				close(lost)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	updated, err := fn(ctx)
+	select {
+	case <-lost:
+		return updated, fmt.Errorf("backfill %d was reclaimed by another instance", id)
+	default:
+	}
+	return updated, err
+}
+
+// runBackfill reprices a provider's requests between start and end for one
+// organization, or for every organization that used it.
+func (s *CostTrackerServer) runBackfill(ctx context.Context, provider string, orgID sql.NullInt64, start, end time.Time) (int64, error) {
+	orgIDs := []int{}
+	if orgID.Valid {
+		orgIDs = append(orgIDs, int(orgID.Int64))
+	} else {
+		rows, err := s.db.QueryContext(ctx, `
+            SELECT DISTINCT organization_id FROM api_requests
+            WHERE provider = $1 AND time >= $2 AND time < $3
+        `, provider, start, end)
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				orgIDs = append(orgIDs, id)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	var updated int64
+	for _, id := range orgIDs {
+		n, err := s.repriceRange(ctx, id, provider, start, end)
+		updated += n
+		if err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+func (s *CostTrackerServer) publishBackfills(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, provider, organization_id, range_start, range_end, status,
+               COALESCE(reason, ''), rows_updated, COALESCE(error, ''), requested_at, finished_at
+        FROM cost_backfills
+        ORDER BY requested_at DESC
+        LIMIT 20
+    `)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	backfills := []CostBackfill{}
+	for rows.Next() {
+		var b CostBackfill
+		var orgID sql.NullInt64
+		var end, finished sql.NullTime
+		var updated int64
+		if err := rows.Scan(&b.ID, &b.Provider, &orgID, &b.RangeStart, &end, &b.Status,
+			&b.Reason, &updated, &b.Error, &b.RequestedAt, &finished); err != nil {
+			continue
+		}
+		b.RowsUpdated = &updated
+		if orgID.Valid {
+			id := int(orgID.Int64)
+			b.OrganizationID = &id
+		}
+		if end.Valid {
+			b.RangeEnd = &end.Time
+		}
+		if finished.Valid {
+			b.FinishedAt = &finished.Time
+		}
+		backfills = append(backfills, b)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Provider-wide backfills are shown to every organization, without the
+	// row count that spans all of them
+	orgIDs, err := s.orgs.OrganizationIDs(ctx)
+	if err != nil {
+		return err
+	}
+	byOrg := map[int]interface{}{}
+	for _, orgID := range orgIDs {
+		list := []CostBackfill{}
+		for _, b := range backfills {
+			switch {
+			case b.OrganizationID == nil:
+				b.RowsUpdated = nil
+			case *b.OrganizationID != orgID:
+				continue
+			}
+			list = append(list, b)
+		}
+		byOrg[orgID] = list
+	}
+	return s.orgs.Publish(ctx, "costs:backfills", byOrg, []CostBackfill{}, 10*jobs.EnvDuration("BACKFILL_INTERVAL", time.Minute))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+const heartbeatQuery = `UPDATE cost_backfills SET heartbeat_at = NOW\(\)`
+
+func newBackfillServer(t *testing.T) (*CostTrackerServer, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &CostTrackerServer{db: db, jobs: jobs.NewScheduler(nil, "cost-tracker")}, mock
+}
+
+func TestClaimedBackfillReclaimed(t *testing.T) {
+	s, mock := newBackfillServer(t)
+	mock.ExpectExec(heartbeatQuery).WithArgs(7, s.jobs.Instance()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(heartbeatQuery).WithArgs(7, s.jobs.Instance()).WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(heartbeatQuery).WithArgs(7, s.jobs.Instance()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	cancelled := false
+	updated, err := s.claimedBackfill(context.Background(), 7, 5*time.Millisecond, func(ctx context.Context) (int64, error) {
+		select {
+		case <-ctx.Done():
+			cancelled = true
+			return 42, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return 100, nil
+		}
+	})
+
+	if !cancelled {
+		t.Fatal("fn was not cancelled when the claim was lost")
+	}
+	if err == nil || !strings.Contains(err.Error(), "backfill 7 was reclaimed") {
+		t.Errorf("claimedBackfill() error = %v, want reclaimed", err)
+	}
+	if updated != 42 {
+		t.Errorf("claimedBackfill() = %d rows, want the 42 updated before cancellation", updated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimedBackfillResult(t *testing.T) {
+	failed := errors.New("price lookup failed")
+
+	tests := []struct {
+		name    string
+		updated int64
+		err     error
+	}{
+		{"success", 12, nil},
+		{"failure", 3, failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newBackfillServer(t)
+			updated, err := s.claimedBackfill(context.Background(), 7, time.Hour, func(ctx context.Context) (int64, error) {
+				return tt.updated, tt.err
+			})
+			if updated != tt.updated || !errors.Is(err, tt.err) {
+				t.Errorf("claimedBackfill() = %d, %v, want %d, %v", updated, err, tt.updated, tt.err)
+			}
+			// No heartbeat is due before fn returns
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/yourusername/api-observatory/cost-tracker/proto"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// costService implements CostTrackerService from shared/proto/cost.proto.
+type costService struct {
+	pb.UnimplementedCostTrackerServiceServer
+	server *CostTrackerServer
+}
+
+func (s *CostTrackerServer) serveGRPC() {
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = "50053"
+	}
+
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", port, err)
+	}
+
+	// Reconciliation uploads whole CSV exports
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxInvoiceSize))
+	pb.RegisterCostTrackerServiceServer(grpcServer, &costService{server: s})
+
+	log.Printf("gRPC server listening on port %s", port)
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve gRPC: %v", err)
+	}
+}
+
+// costGroupings maps group_by to the label expression used against raw rows
+// and against the hourly aggregates, whose time column is bucket.
+var costGroupings = map[string]struct {
+	raw       string
+	aggregate string
+}{
+	"provider": {"provider", "provider"},
+	"endpoint": {"endpoint", "endpoint"},
+	"hour":     {hourLabel("time"), hourLabel("bucket")},
+	"day":      {dayLabel("time"), dayLabel("bucket")},
+}
+
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+// tagGrouping returns the metadata key of a "tag:<key>" group_by.
+func tagGrouping(groupBy string) (string, bool) {
+	key, ok := strings.CutPrefix(groupBy, "tag:")
+	return key, ok && tagKeyPattern.MatchString(key)
+}
+
+func hourLabel(column string) string {
+	return `to_char(time_bucket('1 hour', ` + column + `) AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:00:00"Z"')`
+}
+
+func dayLabel(column string) string {
+	return `to_char(time_bucket('1 day', ` + column + `) AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+}
+
+// GetCostBreakdown returns an organization's cost over [start_time, end_time)
+// grouped by provider, endpoint, hour or day. Times are Unix milliseconds,
+// like APIRequest.timestamp, and default to the last 24 hours.
+func (c *costService) GetCostBreakdown(ctx context.Context, req *pb.CostRequest) (*pb.CostResponse, error) {
+	orgID, err := strconv.Atoi(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid organization_id %q", req.OrganizationId)
+	}
+
+	end := time.Now()
+	if req.EndTime > 0 {
+		end = time.UnixMilli(req.EndTime)
+	}
+	start := end.Add(-24 * time.Hour)
+	if req.StartTime > 0 {
+		start = time.UnixMilli(req.StartTime)
+	}
+	if !start.Before(end) {
+		return nil, status.Error(codes.InvalidArgument, "start_time must be before end_time")
+	}
+
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "provider"
+	}
+	if _, ok := costGroupings[groupBy]; !ok {
+		if _, isTag := tagGrouping(groupBy); !isTag {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported group_by %q (provider, endpoint, hour, day or tag:<key>)", groupBy)
+		}
+	}
+
+	breakdown, err := c.server.costBreakdown(ctx, orgID, start, end, groupBy)
+	if err != nil {
+		log.Printf("Cost breakdown for org %d failed: %v", orgID, err)
+		return nil, status.Error(codes.Internal, "failed to compute cost breakdown")
+	}
+
+	resp := &pb.CostResponse{}
+	for _, item := range breakdown {
+		resp.Breakdown = append(resp.Breakdown, &pb.CostBreakdown{
+			Label:        item.Label,
+			Cost:         item.Cost,
+			RequestCount: item.RequestCount,
+			AvgLatency:   item.AvgLatency,
+			ErrorCount:   int32(item.ErrorCount),
+			Untagged:     item.Untagged,
+		})
+		resp.TotalCost += item.Cost
+		resp.TotalRequests += item.RequestCount
+	}
+	return resp, nil
+}
+
+// costSpan is a part of a breakdown range read either from raw rows or from
+// the hourly aggregates.
+type costSpan struct {
+	from, to  time.Time
+	aggregate bool
+}
+
+// costSpans splits [start, end) for a breakdown. Ranges up to maxRaw are read
+// from raw rows. Longer ranges read whole hours from the hourly continuous
+// aggregates and only the partial hours at either end from raw rows. The aggregates carry no metadata, so tag
+// breakdowns always read raw rows. Empty spans are dropped.
+func costSpans(start, end time.Time, maxRaw time.Duration, tag bool) []costSpan {
+	fullStart := start.Truncate(time.Hour)
+	if fullStart.Before(start) {
+		fullStart = fullStart.Add(time.Hour)
+	}
+	fullEnd := end.Truncate(time.Hour)
+	if end.Sub(start) <= maxRaw || !fullStart.Before(fullEnd) || tag {
+		return []costSpan{{start, end, false}}
+	}
+
+	spans := []costSpan{}
+	for _, sp := range []costSpan{
+		{start, fullStart, false},
+		{fullStart, fullEnd, true},
+		{fullEnd, end, false},
+	} {
+		if sp.from.Before(sp.to) {
+			spans = append(spans, sp)
+		}
+	}
+	return spans
+}
+
+// costBreakdown merges the breakdowns of the range's raw and aggregate spans.
+func (s *CostTrackerServer) costBreakdown(ctx context.Context, orgID int, start, end time.Time, groupBy string) ([]CostBreakdown, error) {
+	maxRaw := jobs.EnvDuration("COST_RAW_MAX_RANGE", 3*time.Hour)
+	_, isTag := tagGrouping(groupBy)
+
+	merged := map[string]*CostBreakdown{}
+	latencySum := map[string]float64{}
+	for _, sp := range costSpans(start, end, maxRaw, isTag) {
+		if err := s.queryCostSpan(ctx, orgID, sp.from, sp.to, groupBy, sp.aggregate, merged, latencySum); err != nil {
+			return nil, err
+		}
+	}
+
+	breakdown := make([]CostBreakdown, 0, len(merged))
+	for label, item := range merged {
+		if item.RequestCount > 0 {
+			item.AvgLatency = latencySum[label] / float64(item.RequestCount)
+		}
+		item.Untagged = isTag && label == untaggedValue
+		breakdown = append(breakdown, *item)
+	}
+
+	if groupBy == "hour" || groupBy == "day" {
+		sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Label < breakdown[j].Label })
+	} else {
+		sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Cost > breakdown[j].Cost })
+	}
+	return breakdown, nil
+}
+
+// queryCostSpan adds one span's rows into merged. Latency is accumulated as
+// a request-weighted sum so spans from different sources combine correctly.
+func (s *CostTrackerServer) queryCostSpan(ctx context.Context, orgID int, from, to time.Time, groupBy string, aggregate bool, merged map[string]*CostBreakdown, latencySum map[string]float64) error {
+	grouping := costGroupings[groupBy]
+	args := []interface{}{orgID, from, to}
+	if key, ok := tagGrouping(groupBy); ok {
+		grouping.raw = `COALESCE(metadata->>$4, '')`
+		args = append(args, key)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            %s as label,
+            COUNT(*) as request_count,
+            COALESCE(SUM(cost), 0) as total_cost,
+            COALESCE(SUM(latency_ms), 0) as latency_sum,
+            COUNT(CASE WHEN status_code >= 400 THEN 1 END) as error_count
+        FROM api_requests
+        WHERE organization_id = $1 AND time >= $2 AND time < $3
+        GROUP BY label
+    `, grouping.raw)
+
+	if aggregate {
+		view := "api_costs_hourly"
+		if groupBy == "endpoint" {
+			view = "api_endpoint_costs_hourly"
+		}
+		query = fmt.Sprintf(`
+            SELECT
+                %s as label,
+                COALESCE(SUM(request_count), 0) as request_count,
+                COALESCE(SUM(total_cost), 0) as total_cost,
+                COALESCE(SUM(avg_latency * request_count), 0) as latency_sum,
+                COALESCE(SUM(error_count), 0) as error_count
+            FROM %s
+            WHERE organization_id = $1 AND bucket >= $2 AND bucket < $3
+            GROUP BY label
+        `, grouping.aggregate, view)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var label string
+		var requests int64
+		var cost, latency float64
+		var errors int
+		if err := rows.Scan(&label, &requests, &cost, &latency, &errors); err != nil {
+			continue
+		}
+
+		item, ok := merged[label]
+		if !ok {
+			item = &CostBreakdown{Label: label}
+			merged[label] = item
+		}
+		item.RequestCount += requests
+		item.Cost += cost
+		item.ErrorCount += errors
+		latencySum[label] += latency
+	}
+	return rows.Err()
+}
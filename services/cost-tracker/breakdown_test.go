@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCostSpans(t *testing.T) {
+	base := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return base.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	format := func(spans []costSpan) string {
+		out := []string{}
+		for _, sp := range spans {
+			kind := "raw"
+			if sp.aggregate {
+				kind = "agg"
+			}
+			out = append(out, fmt.Sprintf("%s %s-%s", kind, sp.from.Format("15:04"), sp.to.Format("15:04")))
+		}
+		return strings.Join(out, ", ")
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		tag        bool
+		expected   string
+	}{
+		{"short range", at(1, 15), at(2, 45), false, "raw 01:15-02:45"},
+		{"exactly the raw limit", at(1, 0), at(4, 0), false, "raw 01:00-04:00"},
+		{"just over the raw limit", at(1, 0), at(4, 1), false, "agg 01:00-04:00, raw 04:00-04:01"},
+		{"aligned hours", at(1, 0), at(9, 0), false, "agg 01:00-09:00"},
+		{"unaligned edges", at(1, 20), at(9, 40), false, "raw 01:20-02:00, agg 02:00-09:00, raw 09:00-09:40"},
+		{"unaligned start only", at(1, 20), at(9, 0), false, "raw 01:20-02:00, agg 02:00-09:00"},
+		{"within one hour", at(1, 20), at(1, 50), false, "raw 01:20-01:50"},
+		{"tag breakdown", at(1, 20), at(9, 40), true, "raw 01:20-09:40"},
+		{"short tag breakdown", at(1, 0), at(2, 0), true, "raw 01:00-02:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format(costSpans(tt.start, tt.end, 3*time.Hour, tt.tag)); got != tt.expected {
+				t.Errorf("costSpans() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	pb "github.com/yourusername/api-observatory/cost-tracker/proto"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var defaultBudgetThresholds = []int64{50, 80, 100}
+
+type budget struct {
+	id          int
+	orgID       int
+	name        string
+	amount      float64
+	period      string
+	periodStart sql.NullTime
+	periodEnd   sql.NullTime
+	provider    sql.NullString
+	tagKey      sql.NullString
+	tagValue    sql.NullString
+	thresholds  []int64
+}
+
+type BudgetStatus struct {
+	BudgetID          int       `json:"budget_id"`
+	OrganizationID    int       `json:"organization_id"`
+	Name              string    `json:"name"`
+	Provider          string    `json:"provider,omitempty"`
+	Tag               string    `json:"tag,omitempty"`
+	Period            string    `json:"period"`
+	PeriodStart       time.Time `json:"period_start"`
+	PeriodEnd         time.Time `json:"period_end"`
+	Amount            float64   `json:"amount"`
+	Spend             float64   `json:"spend"`
+	Percent           float64   `json:"percent"`
+	Remaining         float64   `json:"remaining"`
+	ThresholdsCrossed []int64   `json:"thresholds_crossed"`
+	Status            string    `json:"status"` // ok, warning or exceeded
+
+	// Projection to the end of the period; tag budgets are not forecast
+	ProjectedSpend    float64    `json:"projected_spend,omitempty"`
+	ProjectedPercent  float64    `json:"projected_percent,omitempty"`
+	ProjectedExceedAt *time.Time `json:"projected_exceed_at,omitempty"`
+}
+
+// currentPeriod returns the budget period containing now. Custom budgets
+// outside their range have no current period.
+func (b budget) currentPeriod(now time.Time) (time.Time, time.Time, bool) {
+	now = now.UTC()
+	switch b.period {
+	case "weekly":
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		start := day.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7), true
+	case "custom":
+		if !b.periodStart.Valid || !b.periodEnd.Valid {
+			return time.Time{}, time.Time{}, false
+		}
+		start, end := b.periodStart.Time, b.periodEnd.Time
+		return start, end, !now.Before(start) && now.Before(end)
+	default:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), true
+	}
+}
+
+func (s *CostTrackerServer) trackBudgets() {
+	interval := jobs.EnvDuration("BUDGET_INTERVAL", time.Minute)
+	s.jobs.Every("budgets", interval, interval, s.evaluateBudgets)
+}
+
+func (s *CostTrackerServer) loadBudgets(ctx context.Context) ([]budget, error) {
+	query := `
+        SELECT
+            id, organization_id, name, amount, period, period_start, period_end,
+            provider, tag_key, tag_value, thresholds
+        FROM budgets
+        ORDER BY organization_id, id
+    `
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []budget{}
+	for rows.Next() {
+		var b budget
+		if err := rows.Scan(&b.id, &b.orgID, &b.name, &b.amount, &b.period, &b.periodStart, &b.periodEnd,
+			&b.provider, &b.tagKey, &b.tagValue, pq.Array(&b.thresholds)); err != nil {
+			log.Printf("Failed to scan budget: %v", err)
+			continue
+		}
+		sort.Slice(b.thresholds, func(i, j int) bool { return b.thresholds[i] < b.thresholds[j] })
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// budgetSpend is the budget's spend from start to now. Tag budgets read raw
+// rows, since the hourly aggregates don't carry metadata.
+func (s *CostTrackerServer) budgetSpend(ctx context.Context, b budget, start, now time.Time) (float64, error) {
+	if b.tagKey.Valid {
+		query := `
+            SELECT COALESCE(SUM(cost), 0)
+            FROM api_requests
+            WHERE
+                organization_id = $1
+                AND time >= $2 AND time < $3
+                AND metadata->>$4 = $5
+                AND ($6 = '' OR provider = $6)
+        `
+		var spend float64
+		err := s.db.QueryRowContext(ctx, query, b.orgID, start, now, b.tagKey.String, b.tagValue.String, b.provider.String).Scan(&spend)
+		return spend, err
+	}
+
+	breakdown, err := s.costBreakdown(ctx, b.orgID, start, now, "provider")
+	if err != nil {
+		return 0, err
+	}
+	spend := 0.0
+	for _, item := range breakdown {
+		if !b.provider.Valid || item.Label == b.provider.String {
+			spend += item.Cost
+		}
+	}
+	return spend, nil
+}
+
+func (s *CostTrackerServer) evaluateBudgets(ctx context.Context) error {
+	budgets, err := s.loadBudgets(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	series, err := s.loadHourlySeries(ctx, now.Truncate(time.Hour).Add(-forecastLookback))
+	if err != nil {
+		log.Printf("Failed to load cost history, skipping budget forecasts: %v", err)
+	}
+
+	statuses := []BudgetStatus{}
+	for _, b := range budgets {
+		start, end, ok := b.currentPeriod(now)
+		if !ok {
+			continue
+		}
+
+		spend, err := s.budgetSpend(ctx, b, start, now)
+		if err != nil {
+			log.Printf("Failed to compute spend for budget %d: %v", b.id, err)
+			continue
+		}
+
+		st := BudgetStatus{
+			BudgetID:          b.id,
+			OrganizationID:    b.orgID,
+			Name:              b.name,
+			Provider:          b.provider.String,
+			Period:            b.period,
+			PeriodStart:       start,
+			PeriodEnd:         end,
+			Amount:            b.amount,
+			Spend:             spend,
+			Percent:           100 * spend / b.amount,
+			Remaining:         b.amount - spend,
+			ThresholdsCrossed: []int64{},
+			Status:            "ok",
+		}
+		if b.tagKey.Valid {
+			st.Tag = b.tagKey.String + "=" + b.tagValue.String
+		}
+
+		st.ThresholdsCrossed = crossedThresholds(st.Percent, b.thresholds)
+		for _, t := range st.ThresholdsCrossed {
+			message := fmt.Sprintf("%s reached %d%% of its $%.2f budget", b.name, t, b.amount)
+			if err := s.fireBudgetAlert(ctx, b, st, t, "actual", message); err != nil {
+				log.Printf("Failed to record budget alert for budget %d: %v", b.id, err)
+			}
+		}
+		if series != nil && !b.tagKey.Valid {
+			s.forecastBudget(ctx, b, &st, series[seriesKey{b.orgID, b.provider.String}], now)
+		}
+
+		if st.Percent >= 100 {
+			st.Status = "exceeded"
+		} else if len(st.ThresholdsCrossed) > 0 {
+			st.Status = "warning"
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	byOrg := map[int]interface{}{}
+	for _, st := range statuses {
+		list, _ := byOrg[st.OrganizationID].([]BudgetStatus)
+		byOrg[st.OrganizationID] = append(list, st)
+	}
+	return s.orgs.Publish(ctx, "costs:budgets", byOrg, []BudgetStatus{}, 10*time.Minute)
+}
+
+// fireBudgetAlert records a threshold crossing and notifies subscribers the
+// first time it is seen in a period.
+func (s *CostTrackerServer) fireBudgetAlert(ctx context.Context, b budget, st BudgetStatus, threshold int64, kind, message string) error {
+	result, err := s.db.ExecContext(ctx, `
+        INSERT INTO budget_alerts (budget_id, period_start, threshold, kind, spend)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (budget_id, period_start, threshold, kind) DO NOTHING
+    `, b.id, st.PeriodStart, threshold, kind, st.Spend)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	event := map[string]interface{}{
+		"type":            "budget_alert",
+		"kind":            kind,
+		"organization_id": b.orgID,
+		"budget_id":       b.id,
+		"name":            b.name,
+		"threshold":       threshold,
+		"spend":           st.Spend,
+		"amount":          b.amount,
+		"percent":         st.Percent,
+		"period_start":    st.PeriodStart,
+		"period_end":      st.PeriodEnd,
+		"message":         message,
+		"timestamp":       time.Now().UnixMilli(),
+	}
+	if kind == "forecast" {
+		event["projected_spend"] = st.ProjectedSpend
+		event["projected_exceed_at"] = st.ProjectedExceedAt
+	}
+	eventJSON, _ := json.Marshal(event)
+	if err := s.redis.Publish(ctx, "api_events", eventJSON).Err(); err != nil {
+		return err
+	}
+
+	log.Printf("Budget alert for org %d: %s", b.orgID, message)
+	return nil
+}
+
+// forecastBudget projects the budget to the end of its period and warns,
+// once per period, when it is on course to be exceeded.
+func (s *CostTrackerServer) forecastBudget(ctx context.Context, b budget, st *BudgetStatus, history hourlySeries, now time.Time) {
+	p := projectCost(history, now, st.PeriodEnd)
+	st.ProjectedSpend = st.Spend + p.total()
+	st.ProjectedPercent = 100 * st.ProjectedSpend / b.amount
+
+	if p.history < minForecastHistory || st.Spend >= b.amount || st.ProjectedSpend <= b.amount {
+		return
+	}
+	at, ok := projectedExceedance(p, st.Spend, b.amount)
+	if !ok {
+		return
+	}
+	st.ProjectedExceedAt = &at
+
+	message := fmt.Sprintf("%s is projected to exceed its $%.2f budget by %.0f%% on the %s (%s)",
+		b.name, b.amount, st.ProjectedPercent-100, ordinalDay(at.Day()), at.Format("Jan 2 15:04 MST"))
+	if err := s.fireBudgetAlert(ctx, b, *st, 100, "forecast", message); err != nil {
+		log.Printf("Failed to record forecast alert for budget %d: %v", b.id, err)
+	}
+}
+
+// crossedThresholds returns the leading thresholds (sorted ascending) that
+// percent has reached.
+func crossedThresholds(percent float64, thresholds []int64) []int64 {
+	crossed := []int64{}
+	for _, t := range thresholds {
+		if percent < float64(t) {
+			break
+		}
+		crossed = append(crossed, t)
+	}
+	return crossed
+}
+
+func ordinalDay(day int) string {
+	suffix := "th"
+	if day%100 < 11 || day%100 > 13 {
+		switch day % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(day) + suffix
+}
+
+// budgetThresholds adds alert, a percentage or a fraction when <= 1, to the
+// default thresholds unless it is already one of them.
+func budgetThresholds(alert float64) []int64 {
+	thresholds := append([]int64{}, defaultBudgetThresholds...)
+	if alert <= 0 {
+		return thresholds
+	}
+	if alert <= 1 {
+		alert *= 100
+	}
+	extra := int64(math.Round(alert))
+	for _, existing := range thresholds {
+		if existing == extra {
+			return thresholds
+		}
+	}
+	return append(thresholds, extra)
+}
+
+// SetBudgetAlert creates or updates the budget for an organization, period
+// and scope. alert_threshold adds a percentage (or a fraction when <= 1) to
+// the default 50/80/100% alerts.
+func (c *costService) SetBudgetAlert(ctx context.Context, req *pb.BudgetAlertRequest) (*pb.BudgetAlertResponse, error) {
+	orgID, err := strconv.Atoi(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid organization_id %q", req.OrganizationId)
+	}
+	if req.MonthlyBudget <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "monthly_budget must be positive")
+	}
+	if (req.TagKey == "") != (req.TagValue == "") {
+		return nil, status.Error(codes.InvalidArgument, "tag_key and tag_value must be set together")
+	}
+
+	period := req.Period
+	if period == "" {
+		period = "monthly"
+	}
+	var periodStart, periodEnd sql.NullTime
+	switch period {
+	case "monthly", "weekly":
+	case "custom":
+		if req.PeriodStart <= 0 || req.PeriodEnd <= req.PeriodStart {
+			return nil, status.Error(codes.InvalidArgument, "custom budgets need period_start before period_end")
+		}
+		periodStart = sql.NullTime{Time: time.UnixMilli(req.PeriodStart), Valid: true}
+		periodEnd = sql.NullTime{Time: time.UnixMilli(req.PeriodEnd), Valid: true}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported period %q (monthly, weekly or custom)", period)
+	}
+
+	thresholds := budgetThresholds(req.AlertThreshold)
+
+	name := req.Name
+	if name == "" {
+		scope := []string{strings.ToUpper(period[:1]) + period[1:]}
+		if req.Provider != "" {
+			scope = append(scope, req.Provider)
+		}
+		if req.TagKey != "" {
+			scope = append(scope, req.TagKey+"="+req.TagValue)
+		}
+		name = strings.Join(scope, " ") + " budget"
+	}
+
+	query := `
+        INSERT INTO budgets (
+            organization_id, name, amount, period, period_start, period_end,
+            provider, tag_key, tag_value, thresholds
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10
+        )
+        ON CONFLICT (
+            organization_id, period,
+            COALESCE(provider, ''), COALESCE(tag_key, ''), COALESCE(tag_value, ''),
+            COALESCE(period_start, 'epoch'::timestamptz)
+        )
+        DO UPDATE SET
+            name = EXCLUDED.name,
+            amount = EXCLUDED.amount,
+            period_end = EXCLUDED.period_end,
+            thresholds = EXCLUDED.thresholds,
+            updated_at = NOW()
+        RETURNING id
+    `
+
+	var id int64
+	err = c.server.db.QueryRowContext(ctx, query, orgID, name, req.MonthlyBudget, period, periodStart, periodEnd,
+		req.Provider, req.TagKey, req.TagValue, pq.Array(thresholds)).Scan(&id)
+	if err != nil {
+		log.Printf("Failed to save budget for org %d: %v", orgID, err)
+		return nil, status.Error(codes.Internal, "failed to save budget")
+	}
+
+	return &pb.BudgetAlertResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("%s of $%.2f saved; alerts at %v%%", name, req.MonthlyBudget, thresholds),
+		BudgetId: id,
+	}, nil
+}
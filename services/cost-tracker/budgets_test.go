@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBudgetCurrentPeriod(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	custom := budget{
+		period:      "custom",
+		periodStart: sql.NullTime{Time: at(3, 10, 0), Valid: true},
+		periodEnd:   sql.NullTime{Time: at(3, 20, 0), Valid: true},
+	}
+	sydney := time.FixedZone("AEDT", 11*60*60)
+
+	tests := []struct {
+		name       string
+		budget     budget
+		now        time.Time
+		start, end time.Time
+		ok         bool
+	}{
+		{"weekly on a Monday", budget{period: "weekly"}, at(3, 3, 0), at(3, 3, 0), at(3, 10, 0), true},
+		{"weekly on a Sunday", budget{period: "weekly"}, at(3, 2, 23), at(2, 24, 0), at(3, 3, 0), true},
+		{"weekly across a month", budget{period: "weekly"}, at(3, 1, 12), at(2, 24, 0), at(3, 3, 0), true},
+		{"weekly in UTC, not local time", budget{period: "weekly"}, time.Date(2025, 3, 3, 8, 0, 0, 0, sydney), at(2, 24, 0), at(3, 3, 0), true},
+		{"monthly", budget{period: "monthly"}, at(3, 15, 12), at(3, 1, 0), at(4, 1, 0), true},
+		{"monthly on the last hour", budget{period: "monthly"}, at(1, 31, 23), at(1, 1, 0), at(2, 1, 0), true},
+		{"monthly rolls over the year", budget{period: "monthly"}, at(12, 31, 23), at(12, 1, 0), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"custom within its range", custom, at(3, 15, 0), at(3, 10, 0), at(3, 20, 0), true},
+		{"custom at its start", custom, at(3, 10, 0), at(3, 10, 0), at(3, 20, 0), true},
+		{"custom before its range", custom, at(3, 9, 23), at(3, 10, 0), at(3, 20, 0), false},
+		{"custom at its end", custom, at(3, 20, 0), at(3, 10, 0), at(3, 20, 0), false},
+		{"custom without a range", budget{period: "custom"}, at(3, 15, 0), time.Time{}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.budget.currentPeriod(tt.now)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) || ok != tt.ok {
+				t.Errorf("currentPeriod() = [%s, %s) %v, want [%s, %s) %v", start, end, ok, tt.start, tt.end, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBudgetThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		alert    float64
+		expected []int64
+	}{
+		{"defaults", 0, []int64{50, 80, 100}},
+		{"percentage", 75, []int64{50, 80, 100, 75}},
+		{"fraction", 0.75, []int64{50, 80, 100, 75}},
+		{"a fraction of one is 100%", 1, []int64{50, 80, 100}},
+		{"rounded", 90.4, []int64{50, 80, 100, 90}},
+		{"duplicate of a default", 80, []int64{50, 80, 100}},
+		{"duplicate of a default as a fraction", 0.5, []int64{50, 80, 100}},
+		{"over budget", 120, []int64{50, 80, 100, 120}},
+		{"negative is ignored", -10, []int64{50, 80, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetThresholds(tt.alert); fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("budgetThresholds(%v) = %v, want %v", tt.alert, got, tt.expected)
+			}
+		})
+	}
+
+	// Callers must not be able to change the defaults through the result
+	budgetThresholds(75)[0] = 1
+	if defaultBudgetThresholds[0] != 50 {
+		t.Errorf("defaultBudgetThresholds changed to %v", defaultBudgetThresholds)
+	}
+}
+
+func TestCrossedThresholds(t *testing.T) {
+	thresholds := []int64{50, 75, 80, 100}
+
+	tests := []struct {
+		name     string
+		percent  float64
+		expected []int64
+	}{
+		{"below every threshold", 49.9, []int64{}},
+		{"exactly on a threshold", 50, []int64{50}},
+		{"between thresholds", 79, []int64{50, 75}},
+		{"at the budget", 100, []int64{50, 75, 80, 100}},
+		{"over the budget", 180, []int64{50, 75, 80, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crossedThresholds(tt.percent, thresholds); fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("crossedThresholds(%v) = %v, want %v", tt.percent, got, tt.expected)
+			}
+		})
+	}
+
+	// Thresholds are sorted on load; the scan stops at the first one not
+	// reached rather than skipping over it
+	if got := crossedThresholds(60, []int64{50, 80, 55}); fmt.Sprint(got) != "[50]" {
+		t.Errorf("crossedThresholds() = %v, want [50]", got)
+	}
+}
+
+func TestOrdinalDay(t *testing.T) {
+	tests := map[int]string{
+		1: "1st", 2: "2nd", 3: "3rd", 4: "4th",
+		11: "11th", 12: "12th", 13: "13th",
+		21: "21st", 22: "22nd", 23: "23rd",
+		30: "30th", 31: "31st",
+	}
+	for day, expected := range tests {
+		if got := ordinalDay(day); got != expected {
+			t.Errorf("ordinalDay(%d) = %q, want %q", day, got, expected)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	pb "github.com/yourusername/api-observatory/cost-tracker/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Alternatives must save at least this fraction to be recommended.
+	minComparisonSavings = 0.05
+	// Acceptable degradation against the current provider.
+	maxErrorRateIncrease = 0.01
+	maxP95LatencyRatio   = 1.5
+)
+
+type pricingPlan struct {
+	provider         string
+	name             string
+	category         string
+	costPerRequest   float64
+	costPerKB        float64
+	monthlyFee       float64
+	includedRequests int64
+}
+
+// price reprices traffic under the plan. Fees and included requests are
+// prorated over the number of months in the range.
+func (p pricingPlan) price(requests int64, kb, months float64) float64 {
+	billable := math.Max(0, float64(requests)-float64(p.includedRequests)*months)
+	return p.monthlyFee*months + billable*p.costPerRequest + kb*p.costPerKB
+}
+
+// providerProfile is a provider's observed latency and provider-side failure
+// rate (5xx, 429, timeouts and outages; client errors are not its fault).
+type providerProfile struct {
+	requests  int64
+	avgMS     float64
+	p95MS     float64
+	errorRate float64
+	global    bool // observed across all organizations, not just this one
+}
+
+func (s *CostTrackerServer) loadPricingPlans(ctx context.Context) ([]pricingPlan, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT provider, plan_name, category, cost_per_request, cost_per_kb, monthly_fee, included_requests
+        FROM pricing_plans
+        ORDER BY provider, plan_name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []pricingPlan{}
+	for rows.Next() {
+		var p pricingPlan
+		if err := rows.Scan(&p.provider, &p.name, &p.category, &p.costPerRequest, &p.costPerKB, &p.monthlyFee, &p.includedRequests); err != nil {
+			continue
+		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+// loadProviderProfiles reads latency and failure rates for providers from
+// the organization's traffic, falling back to all organizations for
+// providers it hasn't used.
+func (s *CostTrackerServer) loadProviderProfiles(ctx context.Context, orgID int, providers []string, start, end time.Time) (map[string]providerProfile, error) {
+	query := `
+        SELECT
+            provider,
+            COUNT(*),
+            COALESCE(AVG(latency_ms), 0),
+            COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
+            COUNT(*) FILTER (WHERE status_code >= 500 OR status_code = 429
+                OR error_class IN ('timeout', 'provider_outage', 'rate_limit'))
+        FROM api_requests
+        WHERE
+            provider = ANY($1)
+            AND time >= $2 AND time < $3
+            AND ($4 = 0 OR organization_id = $4)
+        GROUP BY provider
+    `
+
+	profiles := map[string]providerProfile{}
+	for _, scope := range []int{orgID, 0} {
+		missing := []string{}
+		for _, p := range providers {
+			if _, ok := profiles[p]; !ok {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+
+		rows, err := s.db.QueryContext(ctx, query, pq.Array(missing), start, end, scope)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var provider string
+			var failures int64
+			var p providerProfile
+			if err := rows.Scan(&provider, &p.requests, &p.avgMS, &p.p95MS, &failures); err != nil {
+				continue
+			}
+			if p.requests > 0 {
+				p.errorRate = float64(failures) / float64(p.requests)
+			}
+			p.global = scope == 0
+			profiles[provider] = p
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// resolveAlternatives picks the plans to compare: "Provider/Plan" names one
+// plan, "Provider" all of its plans, and no list every plan in the current
+// provider's category.
+func resolveAlternatives(plans []pricingPlan, current string, requested []string) []pricingPlan {
+	if len(requested) == 0 {
+		categories := map[string]bool{}
+		for _, p := range plans {
+			if p.provider == current {
+				categories[p.category] = true
+			}
+		}
+		selected := []pricingPlan{}
+		for _, p := range plans {
+			if categories[p.category] {
+				selected = append(selected, p)
+			}
+		}
+		return selected
+	}
+
+	selected := []pricingPlan{}
+	for _, name := range requested {
+		provider, plan, _ := strings.Cut(name, "/")
+		for _, p := range plans {
+			if strings.EqualFold(p.provider, strings.TrimSpace(provider)) && (plan == "" || strings.EqualFold(p.name, strings.TrimSpace(plan))) {
+				selected = append(selected, p)
+			}
+		}
+	}
+	return selected
+}
+
+func performanceNotes(profile providerProfile, ok bool, current providerProfile) string {
+	if !ok || profile.requests == 0 {
+		return "No observed traffic; latency and reliability are unknown, so run a trial before switching."
+	}
+
+	scope := "your"
+	if profile.global {
+		scope = "other organizations'"
+	}
+	notes := fmt.Sprintf("p95 latency %.0fms (avg %.0fms), %.2f%% provider-side failures over %d of %s requests.",
+		profile.p95MS, profile.avgMS, 100*profile.errorRate, profile.requests, scope)
+	if current.requests > 0 && current.p95MS > 0 && profile.p95MS > maxP95LatencyRatio*current.p95MS {
+		notes += fmt.Sprintf(" Noticeably slower than the current provider (p95 %.0fms).", current.p95MS)
+	}
+	if current.requests > 0 && profile.errorRate > current.errorRate+maxErrorRateIncrease {
+		notes += fmt.Sprintf(" Less reliable than the current provider (%.2f%% failures).", 100*current.errorRate)
+	}
+	return notes
+}
+
+// GetProviderComparison reprices the organization's actual traffic to the
+// current provider under alternative providers and plans.
+func (c *costService) GetProviderComparison(ctx context.Context, req *pb.ComparisonRequest) (*pb.ComparisonResponse, error) {
+	orgID, err := strconv.Atoi(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid organization_id %q", req.OrganizationId)
+	}
+	if req.CurrentProvider == "" {
+		return nil, status.Error(codes.InvalidArgument, "current_provider is required")
+	}
+
+	end := time.Now()
+	if req.EndTime > 0 {
+		end = time.UnixMilli(req.EndTime)
+	}
+	start := end.Add(-30 * 24 * time.Hour)
+	if req.StartTime > 0 {
+		start = time.UnixMilli(req.StartTime)
+	}
+	if !start.Before(end) {
+		return nil, status.Error(codes.InvalidArgument, "start_time must be before end_time")
+	}
+
+	resp, err := c.server.compareProviders(ctx, orgID, req.CurrentProvider, req.AlternativeProviders, start, end)
+	if err != nil {
+		log.Printf("Provider comparison for org %d failed: %v", orgID, err)
+		return nil, status.Error(codes.Internal, "failed to compare providers")
+	}
+	return resp, nil
+}
+
+func (s *CostTrackerServer) compareProviders(ctx context.Context, orgID int, current string, requested []string, start, end time.Time) (*pb.ComparisonResponse, error) {
+	var requests int64
+	var actual, kb float64
+	err := s.db.QueryRowContext(ctx, `
+        SELECT
+            COUNT(*),
+            COALESCE(SUM(cost), 0),
+            COALESCE(SUM(COALESCE(request_size_bytes, 0) + COALESCE(response_size_bytes, 0)), 0)::float8 / 1024
+        FROM api_requests
+        WHERE organization_id = $1 AND provider = $2 AND time >= $3 AND time < $4
+    `, orgID, current, start, end).Scan(&requests, &actual, &kb)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := s.loadPricingPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alternatives := resolveAlternatives(plans, current, requested)
+
+	providers := []string{current}
+	for _, p := range alternatives {
+		providers = append(providers, p.provider)
+	}
+	profiles, err := s.loadProviderProfiles(ctx, orgID, providers, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	months := end.Sub(start).Hours() / (24 * 30)
+	return comparePlans(current, alternatives, profiles, requests, kb, actual, months), nil
+}
+
+// comparePlans prices the traffic to the current provider under each
+// alternative plan and recommends the cheapest one that saves enough
+// without worse latency or reliability.
+func comparePlans(current string, alternatives []pricingPlan, profiles map[string]providerProfile, requests int64, kb, actual, months float64) *pb.ComparisonResponse {
+	currentProfile := profiles[current]
+	resp := &pb.ComparisonResponse{CurrentCost: actual, RequestCount: requests}
+
+	var best *pb.ProviderCost
+	for _, plan := range alternatives {
+		estimated := plan.price(requests, kb, months)
+		profile, ok := profiles[plan.provider]
+
+		pc := &pb.ProviderCost{
+			Provider:         plan.provider,
+			Plan:             plan.name,
+			EstimatedCost:    estimated,
+			CostDifference:   estimated - actual,
+			PerformanceNotes: performanceNotes(profile, ok, currentProfile),
+			ObservedRequests: profile.requests,
+			AvgLatencyMs:     profile.avgMS,
+			P95LatencyMs:     profile.p95MS,
+			ErrorRate:        profile.errorRate,
+		}
+		resp.Providers = append(resp.Providers, pc)
+
+		acceptable := !ok || profile.requests == 0 || currentProfile.requests == 0 ||
+			(profile.errorRate <= currentProfile.errorRate+maxErrorRateIncrease &&
+				(currentProfile.p95MS == 0 || profile.p95MS <= maxP95LatencyRatio*currentProfile.p95MS))
+		if acceptable && estimated < actual*(1-minComparisonSavings) && (best == nil || estimated < best.EstimatedCost) {
+			best = pc
+		}
+	}
+
+	sort.Slice(resp.Providers, func(i, j int) bool {
+		return resp.Providers[i].EstimatedCost < resp.Providers[j].EstimatedCost
+	})
+
+	switch {
+	case requests == 0:
+		resp.Recommendation = fmt.Sprintf("No %s traffic in the selected range, so there is nothing to reprice.", current)
+	case len(alternatives) == 0:
+		resp.Recommendation = fmt.Sprintf("No pricing plans found to compare against %s; add them to pricing_plans.", current)
+	case best == nil:
+		resp.Recommendation = fmt.Sprintf("Stay on %s: no alternative would have saved more than %.0f%% without worse latency or reliability.",
+			current, 100*minComparisonSavings)
+	default:
+		saving := actual - best.EstimatedCost
+		resp.Recommendation = fmt.Sprintf("%s (%s) would have cost $%.2f instead of $%.2f for these %d requests, saving $%.2f (%.0f%%). %s",
+			best.Provider, best.Plan, best.EstimatedCost, actual, requests, saving, 100*saving/actual, best.PerformanceNotes)
+	}
+	return resp
+}
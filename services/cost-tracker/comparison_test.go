@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPricingPlanPrice(t *testing.T) {
+	plan := pricingPlan{costPerRequest: 0.001, costPerKB: 0.0001, monthlyFee: 20, includedRequests: 10000}
+
+	tests := []struct {
+		name     string
+		requests int64
+		kb       float64
+		months   float64
+		expected float64
+	}{
+		{"within the allowance", 8000, 0, 1, 20},
+		{"over the allowance", 15000, 0, 1, 20 + 5},
+		{"half a month prorates fee and allowance", 15000, 0, 0.5, 10 + 10},
+		{"two months double the allowance", 15000, 0, 2, 40},
+		{"size charge", 10000, 500, 1, 20 + 0.05},
+		{"no traffic", 0, 0, 1, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plan.price(tt.requests, tt.kb, tt.months); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("price() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveAlternatives(t *testing.T) {
+	plans := []pricingPlan{
+		{provider: "Stripe", name: "Standard", category: "payments"},
+		{provider: "Adyen", name: "Basic", category: "payments"},
+		{provider: "Adyen", name: "Volume", category: "payments"},
+		{provider: "SendGrid", name: "Pro", category: "email"},
+	}
+	names := func(selected []pricingPlan) string {
+		out := []string{}
+		for _, p := range selected {
+			out = append(out, p.provider+"/"+p.name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name      string
+		requested []string
+		expected  string
+	}{
+		{"same category by default", nil, "Stripe/Standard,Adyen/Basic,Adyen/Volume"},
+		{"every plan of a provider", []string{"adyen"}, "Adyen/Basic,Adyen/Volume"},
+		{"one plan", []string{"Adyen / volume"}, "Adyen/Volume"},
+		{"other category on request", []string{"SendGrid"}, "SendGrid/Pro"},
+		{"unknown provider", []string{"Braintree"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(resolveAlternatives(plans, "Stripe", tt.requested)); got != tt.expected {
+				t.Errorf("resolveAlternatives() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComparePlans(t *testing.T) {
+	current := providerProfile{requests: 1000, p95MS: 200, errorRate: 0.01}
+	cheap := pricingPlan{provider: "Cheap", name: "Basic", costPerRequest: 0.0009}        // 10% cheaper
+	marginal := pricingPlan{provider: "Marginal", name: "Basic", costPerRequest: 0.00096} // 4% cheaper
+	cheapest := pricingPlan{provider: "Cheapest", name: "Basic", costPerRequest: 0.0005}
+
+	tests := []struct {
+		name         string
+		alternatives []pricingPlan
+		profiles     map[string]providerProfile
+		requests     int64
+		best         string // empty when the advice is to stay
+	}{
+		{"cheaper and as good", []pricingPlan{cheap}, map[string]providerProfile{"Cheap": {requests: 500, p95MS: 220, errorRate: 0.015}}, 1000, "Cheap"},
+		{"saving below the threshold", []pricingPlan{marginal}, map[string]providerProfile{"Marginal": current}, 1000, ""},
+		{"unobserved provider is not rejected", []pricingPlan{cheap}, map[string]providerProfile{}, 1000, "Cheap"},
+		{"too slow", []pricingPlan{cheap}, map[string]providerProfile{"Cheap": {requests: 500, p95MS: 301, errorRate: 0.01}}, 1000, ""},
+		{"latency at the limit", []pricingPlan{cheap}, map[string]providerProfile{"Cheap": {requests: 500, p95MS: 300, errorRate: 0.01}}, 1000, "Cheap"},
+		{"too many failures", []pricingPlan{cheap}, map[string]providerProfile{"Cheap": {requests: 500, p95MS: 200, errorRate: 0.021}}, 1000, ""},
+		{
+			"cheapest acceptable plan wins",
+			[]pricingPlan{cheapest, cheap},
+			map[string]providerProfile{"Cheapest": {requests: 500, p95MS: 500}, "Cheap": {requests: 500, p95MS: 200}},
+			1000,
+			"Cheap",
+		},
+		{"no traffic to reprice", []pricingPlan{cheap}, map[string]providerProfile{}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles := map[string]providerProfile{"Current": current}
+			for name, p := range tt.profiles {
+				profiles[name] = p
+			}
+			actual := float64(tt.requests) * 0.001
+			resp := comparePlans("Current", tt.alternatives, profiles, tt.requests, 0, actual, 1)
+
+			if len(resp.Providers) != len(tt.alternatives) {
+				t.Fatalf("got %d providers, want %d", len(resp.Providers), len(tt.alternatives))
+			}
+			for i, pc := range resp.Providers {
+				if math.Abs(pc.CostDifference-(pc.EstimatedCost-actual)) > 1e-9 {
+					t.Errorf("%s cost difference = %v, want %v", pc.Provider, pc.CostDifference, pc.EstimatedCost-actual)
+				}
+				if i > 0 && resp.Providers[i-1].EstimatedCost > pc.EstimatedCost {
+					t.Errorf("providers not sorted by estimated cost at %s", pc.Provider)
+				}
+			}
+
+			got := ""
+			for _, p := range tt.alternatives {
+				if strings.HasPrefix(resp.Recommendation, p.provider+" (") {
+					got = p.provider
+				}
+			}
+			if got != tt.best {
+				t.Errorf("recommended %q, want %q: %s", got, tt.best, resp.Recommendation)
+			}
+		})
+	}
+}
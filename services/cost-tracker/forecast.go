@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+const (
+	// Hours of api_costs_hourly history the forecast model is fit on.
+	forecastLookback = 28 * 24 * time.Hour
+	// z for a 95% confidence interval.
+	forecastZ = 1.96
+	// Forecast-based budget warnings wait for this much history.
+	minForecastHistory = 24
+)
+
+type DailyForecast struct {
+	Date     string  `json:"date"`
+	Actual   float64 `json:"actual"`
+	Expected float64 `json:"expected"`
+}
+
+// Forecast projects spend to the end of a period. Provider is empty for an
+// organization's total.
+type Forecast struct {
+	OrganizationID int             `json:"organization_id"`
+	Provider       string          `json:"provider,omitempty"`
+	PeriodStart    time.Time       `json:"period_start"`
+	PeriodEnd      time.Time       `json:"period_end"`
+	SpendToDate    float64         `json:"spend_to_date"`
+	ProjectedSpend float64         `json:"projected_spend"`
+	Lower          float64         `json:"lower"`
+	Upper          float64         `json:"upper"`
+	Confidence     float64         `json:"confidence"`
+	Model          string          `json:"model"`
+	HistoryHours   int             `json:"history_hours"`
+	Daily          []DailyForecast `json:"daily"`
+}
+
+type seriesKey struct {
+	orgID    int
+	provider string
+}
+
+// hourlySeries maps hour buckets to cost.
+type hourlySeries map[time.Time]float64
+
+// costProjection is the expected cost of each remaining hour of a period,
+// starting with the current, partly elapsed hour.
+type costProjection struct {
+	start    time.Time
+	expected []float64
+	sigma    float64
+	model    string
+	history  int
+}
+
+func (p costProjection) total() float64 {
+	sum := 0.0
+	for _, v := range p.expected {
+		sum += v
+	}
+	return sum
+}
+
+// interval is the 95% half-width of the projected total, treating hourly
+// residuals as independent.
+func (p costProjection) interval() float64 {
+	return forecastZ * p.sigma * math.Sqrt(float64(len(p.expected)))
+}
+
+// loadHourlySeries reads hourly cost per organization and provider since
+// from, plus each organization's total under an empty provider.
+func (s *CostTrackerServer) loadHourlySeries(ctx context.Context, from time.Time) (map[seriesKey]hourlySeries, error) {
+	query := `
+        SELECT bucket, organization_id, provider, COALESCE(total_cost, 0)
+        FROM api_costs_hourly
+        WHERE bucket >= $1
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := map[seriesKey]hourlySeries{}
+	add := func(key seriesKey, bucket time.Time, cost float64) {
+		if series[key] == nil {
+			series[key] = hourlySeries{}
+		}
+		series[key][bucket] += cost
+	}
+
+	for rows.Next() {
+		var bucket time.Time
+		var orgID int
+		var provider string
+		var cost float64
+		if err := rows.Scan(&bucket, &orgID, &provider, &cost); err != nil {
+			continue
+		}
+		bucket = bucket.UTC()
+		add(seriesKey{orgID, provider}, bucket, cost)
+		add(seriesKey{orgID, ""}, bucket, cost)
+	}
+	return series, rows.Err()
+}
+
+// projectCost fits a seasonal profile to the history before the current hour
+// and projects it to end. With two weeks of history the profile is by hour of
+// week, with two days by hour of day, and otherwise a flat hourly rate. The
+// profile is scaled by the last week's level relative to the whole history.
+func projectCost(history hourlySeries, now, end time.Time) costProjection {
+	current := now.UTC().Truncate(time.Hour)
+
+	first := current
+	for bucket := range history {
+		if bucket.Before(first) && !bucket.Before(current.Add(-forecastLookback)) {
+			first = bucket
+		}
+	}
+
+	values := []float64{}
+	for t := first; t.Before(current); t = t.Add(time.Hour) {
+		values = append(values, history[t])
+	}
+
+	model, slots := "flat", 1
+	slot := func(t time.Time) int { return 0 }
+	switch {
+	case len(values) >= 14*24:
+		model, slots = "hour_of_week", 168
+		slot = func(t time.Time) int { return int(t.Weekday())*24 + t.Hour() }
+	case len(values) >= 48:
+		model, slots = "hour_of_day", 24
+		slot = func(t time.Time) int { return t.Hour() }
+	}
+
+	sums := make([]float64, slots)
+	counts := make([]int, slots)
+	total := 0.0
+	for i, v := range values {
+		k := slot(first.Add(time.Duration(i) * time.Hour))
+		sums[k] += v
+		counts[k]++
+		total += v
+	}
+	profile := make([]float64, slots)
+	for k := range profile {
+		if counts[k] > 0 {
+			profile[k] = sums[k] / float64(counts[k])
+		}
+	}
+
+	level := 1.0
+	if len(values) > 0 && total > 0 {
+		recent := values[max(0, len(values)-168):]
+		recentSum := 0.0
+		for _, v := range recent {
+			recentSum += v
+		}
+		level = (recentSum / float64(len(recent))) / (total / float64(len(values)))
+		level = math.Min(math.Max(level, 0.5), 2)
+	}
+
+	sqErr := 0.0
+	for i, v := range values {
+		d := v - profile[slot(first.Add(time.Duration(i)*time.Hour))]
+		sqErr += d * d
+	}
+	sigma := 0.0
+	if len(values) > 0 {
+		sigma = math.Sqrt(sqErr / float64(len(values)))
+	}
+
+	p := costProjection{start: current, sigma: sigma, model: model, history: len(values)}
+	for t := current; t.Before(end); t = t.Add(time.Hour) {
+		expected := profile[slot(t)] * level
+		if t.Equal(current) {
+			// Part of the current hour has already been spent
+			expected = math.Max(0, expected-history[current])
+		}
+		p.expected = append(p.expected, expected)
+	}
+	return p
+}
+
+// buildForecast combines actual spend since start with the projection to end.
+func buildForecast(key seriesKey, history hourlySeries, start, end, now time.Time) Forecast {
+	p := projectCost(history, now, end)
+
+	f := Forecast{
+		OrganizationID: key.orgID,
+		Provider:       key.provider,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Confidence:     0.95,
+		Model:          p.model,
+		HistoryHours:   p.history,
+		Daily:          []DailyForecast{},
+	}
+
+	index := map[string]int{}
+	addDaily := func(t time.Time, actual, expected float64) {
+		label := t.Format("2006-01-02")
+		i, ok := index[label]
+		if !ok {
+			f.Daily = append(f.Daily, DailyForecast{Date: label})
+			i = len(f.Daily) - 1
+			index[label] = i
+		}
+		f.Daily[i].Actual += actual
+		f.Daily[i].Expected += expected
+	}
+
+	// History is hourly, so a period starting mid-hour counts that whole hour
+	for t := start.Truncate(time.Hour); !t.After(p.start); t = t.Add(time.Hour) {
+		f.SpendToDate += history[t]
+		addDaily(t, history[t], 0)
+	}
+	for i, v := range p.expected {
+		addDaily(p.start.Add(time.Duration(i)*time.Hour), 0, v)
+	}
+
+	half := p.interval()
+	f.ProjectedSpend = f.SpendToDate + p.total()
+	f.Lower = math.Max(f.SpendToDate, f.ProjectedSpend-half)
+	f.Upper = f.ProjectedSpend + half
+	return f
+}
+
+func (s *CostTrackerServer) trackForecasts() {
+	interval := jobs.EnvDuration("FORECAST_INTERVAL", 15*time.Minute)
+	s.jobs.Every("forecasts", interval, interval, s.calculateForecasts)
+}
+
+// calculateForecasts projects month-end spend for every organization and
+// provider seen in the lookback window.
+func (s *CostTrackerServer) calculateForecasts(ctx context.Context) error {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	from := now.Truncate(time.Hour).Add(-forecastLookback)
+	if monthStart.Before(from) {
+		from = monthStart
+	}
+	series, err := s.loadHourlySeries(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	forecasts := []Forecast{}
+	for key, history := range series {
+		forecasts = append(forecasts, buildForecast(key, history, monthStart, monthEnd, now))
+	}
+	sortForecasts(forecasts)
+
+	byOrg := map[int][]Forecast{}
+	for _, f := range forecasts {
+		byOrg[f.OrganizationID] = append(byOrg[f.OrganizationID], f)
+	}
+	published := map[int]interface{}{}
+	for orgID, list := range byOrg {
+		published[orgID] = map[string]interface{}{"forecasts": list, "updated_at": now}
+	}
+	empty := map[string]interface{}{"forecasts": []Forecast{}, "updated_at": now}
+	if err := s.orgs.Publish(ctx, "costs:forecast", published, empty, 2*jobs.EnvDuration("FORECAST_INTERVAL", 15*time.Minute)); err != nil {
+		return err
+	}
+
+	log.Printf("Forecast complete: %d series projected to %s", len(forecasts), monthEnd.Format("2006-01-02"))
+	return nil
+}
+
+// sortForecasts orders by organization, with each organization's total first
+// and then providers by projected spend.
+func sortForecasts(forecasts []Forecast) {
+	sort.Slice(forecasts, func(i, j int) bool {
+		a, b := forecasts[i], forecasts[j]
+		if a.OrganizationID != b.OrganizationID {
+			return a.OrganizationID < b.OrganizationID
+		}
+		if (a.Provider == "") != (b.Provider == "") {
+			return a.Provider == ""
+		}
+		return a.ProjectedSpend > b.ProjectedSpend
+	})
+}
+
+// projectedExceedance walks the projection forward from spend and returns
+// when the running total first reaches amount.
+func projectedExceedance(p costProjection, spend, amount float64) (time.Time, bool) {
+	running := spend
+	for i, v := range p.expected {
+		running += v
+		if running >= amount {
+			return p.start.Add(time.Duration(i+1) * time.Hour), true
+		}
+	}
+	return time.Time{}, false
+}
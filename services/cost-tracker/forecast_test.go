@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProjectCost(t *testing.T) {
+	now := time.Date(2025, 3, 10, 10, 30, 0, 0, time.UTC)
+	current := now.Truncate(time.Hour)
+	series := func(hours int, cost func(t time.Time) float64) hourlySeries {
+		h := hourlySeries{}
+		for i := 1; i <= hours; i++ {
+			at := current.Add(-time.Duration(i) * time.Hour)
+			h[at] = cost(at)
+		}
+		return h
+	}
+	flat := func(time.Time) float64 { return 2 }
+	byHour := func(t time.Time) float64 { return float64(t.Hour()) }
+
+	withCurrent := series(10, flat)
+	withCurrent[current] = 0.5
+	stale := hourlySeries{current.Add(-29 * 24 * time.Hour): 100}
+
+	tests := []struct {
+		name     string
+		history  hourlySeries
+		end      time.Time
+		model    string
+		hours    int
+		expected []float64
+	}{
+		{"no history", hourlySeries{}, current.Add(3 * time.Hour), "flat", 0, []float64{0, 0, 0}},
+		{"history past the lookback is ignored", stale, current.Add(2 * time.Hour), "flat", 0, []float64{0, 0}},
+		{"flat rate", series(10, flat), current.Add(3 * time.Hour), "flat", 10, []float64{2, 2, 2}},
+		{"current hour spend is subtracted", withCurrent, current.Add(3 * time.Hour), "flat", 10, []float64{1.5, 2, 2}},
+		{"gaps count as zero cost", hourlySeries{current.Add(-4 * time.Hour): 4}, current.Add(time.Hour), "flat", 4, []float64{1}},
+		{"hour of day", series(72, byHour), current.Add(3 * time.Hour), "hour_of_day", 72, []float64{10, 11, 12}},
+		{"hour of week", series(14*24, byHour), current.Add(2 * time.Hour), "hour_of_week", 14 * 24, []float64{10, 11}},
+		{"period already over", series(10, flat), current, "flat", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := projectCost(tt.history, now, tt.end)
+			if p.model != tt.model || p.history != tt.hours {
+				t.Errorf("projectCost() model %q on %d hours, want %q on %d", p.model, p.history, tt.model, tt.hours)
+			}
+			if !p.start.Equal(current) {
+				t.Errorf("projectCost() starts at %s, want %s", p.start, current)
+			}
+			if len(p.expected) != len(tt.expected) {
+				t.Fatalf("projectCost() = %v, want %v", p.expected, tt.expected)
+			}
+			for i := range p.expected {
+				if math.Abs(p.expected[i]-tt.expected[i]) > 1e-9 {
+					t.Fatalf("projectCost() = %v, want %v", p.expected, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectedExceedance(t *testing.T) {
+	start := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	p := costProjection{start: start, expected: []float64{1, 2, 3}}
+
+	tests := []struct {
+		name   string
+		spend  float64
+		amount float64
+		want   time.Time
+		wantOK bool
+	}{
+		{"reached in the current hour", 9, 10, start.Add(time.Hour), true},
+		{"reached exactly", 0, 3, start.Add(2 * time.Hour), true},
+		{"reached in the last hour", 0, 6, start.Add(3 * time.Hour), true},
+		{"not reached", 0, 6.01, time.Time{}, false},
+		{"already over budget", 20, 10, start.Add(time.Hour), true},
+	}
+	for _, tt := range tests {
+		got, ok := projectedExceedance(p, tt.spend, tt.amount)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: projectedExceedance() = %s, %v; want %s, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -3,18 +3,21 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"log"
 	"os"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
+	"github.com/yourusername/api-observatory/shared/jobs"
+	"github.com/yourusername/api-observatory/shared/tenant"
 )
 
 type CostTrackerServer struct {
 	db    *sql.DB
 	redis *redis.Client
+	jobs  *jobs.Scheduler
+	orgs  *tenant.Publisher
 }
 
 type CostBreakdown struct {
@@ -23,6 +26,11 @@ type CostBreakdown struct {
 	RequestCount int64   `json:"request_count"`
 	AvgLatency   float64 `json:"avg_latency"`
 	ErrorCount   int     `json:"error_count"`
+	Untagged     bool    `json:"untagged,omitempty"` // tag breakdowns only; Label is empty
+
+	// Failures by class (auth, rate_limit, validation, ...). Calls from
+	// before classification was added count as "unclassified".
+	ErrorClasses map[string]int `json:"error_classes,omitempty"`
 }
 
 func main() {
@@ -42,69 +50,112 @@ func main() {
 	server := &CostTrackerServer{
 		db:    db,
 		redis: rdb,
+		jobs:  jobs.NewScheduler(rdb, "cost-tracker"),
+		orgs:  tenant.NewPublisher(db, rdb),
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		os.Exit(server.runReconcile(os.Args[2:]))
 	}
 
 	// Start background cost aggregation
 	go server.aggregateCosts()
+	go server.trackWastedSpend()
+	go server.trackBudgets()
+	go server.trackForecasts()
+	go server.trackChargeback()
+	go server.trackPricing()
+	go server.trackBackfills()
+	go server.checkCostRollup()
+	go server.serveGRPC()
 
 	log.Println("Cost-tracker running and aggregating costs...")
 	select {} // block forever
 }
 
 func (s *CostTrackerServer) aggregateCosts() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	interval := jobs.EnvDuration("AGGREGATION_INTERVAL", time.Minute)
+	s.jobs.Every("real_time_costs", interval, interval, s.calculateRealTimeCosts)
+}
 
-	for {
-		s.calculateRealTimeCosts()
-		<-ticker.C
+// calculateRealTimeCosts publishes each organization's last 24 hours of
+// costs by provider from the per-minute rollups, rather than rescanning
+// api_requests.
+func (s *CostTrackerServer) calculateRealTimeCosts(ctx context.Context) error {
+	now := time.Now()
+	totals, err := s.rollingCosts(ctx, now)
+	if err != nil {
+		return err
 	}
-}
 
-func (s *CostTrackerServer) calculateRealTimeCosts() {
-	ctx := context.Background()
+	breakdowns := totals.breakdowns()
+	totalCost := 0.0
+	orgTotals := map[int]float64{}
+	for orgID, breakdown := range breakdowns {
+		for _, item := range breakdown {
+			orgTotals[orgID] += item.Cost
+		}
+		totalCost += orgTotals[orgID]
+	}
 
-	// Get costs by provider for last 24 hours
+	// Cache in Redis for dashboard, one key per organization
+	byOrg := map[int]interface{}{}
+	for orgID, breakdown := range breakdowns {
+		byOrg[orgID] = map[string]interface{}{
+			"breakdown":  breakdown,
+			"total_cost": orgTotals[orgID],
+			"updated_at": now,
+		}
+	}
+	empty := map[string]interface{}{
+		"breakdown":  []CostBreakdown{},
+		"total_cost": 0,
+		"updated_at": now,
+	}
+	if err := s.orgs.Publish(ctx, "costs:24h:by_provider", byOrg, empty, 5*time.Minute); err != nil {
+		return err
+	}
+
+	log.Printf("Cost aggregation complete: $%.4f across %d organizations", totalCost, len(breakdowns))
+	return nil
+}
+
+// errorClassesByProvider counts failures between start and end by
+// organization, provider and error class.
+func (s *CostTrackerServer) errorClassesByProvider(ctx context.Context, start, end time.Time) (map[int]map[string]map[string]int, error) {
 	query := `
         SELECT
+            organization_id,
             provider,
-            COUNT(*) as request_count,
-            SUM(cost) as total_cost,
-            AVG(latency_ms) as avg_latency,
-            COUNT(CASE WHEN status_code >= 400 THEN 1 END) as error_count
+            COALESCE(error_class, 'unclassified') as error_class,
+            COUNT(*)
         FROM api_requests
-        WHERE time > NOW() - INTERVAL '24 hours'
-        GROUP BY provider
-        ORDER BY total_cost DESC
+        WHERE
+            time >= $1 AND time < $2
+            AND (status_code >= 400 OR error_class IS NOT NULL)
+        GROUP BY organization_id, provider, error_class
     `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, start, end)
 	if err != nil {
-		log.Printf("Failed to calculate costs: %v", err)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	breakdown := []CostBreakdown{}
-	totalCost := 0.0
-
+	classes := map[int]map[string]map[string]int{}
 	for rows.Next() {
-		var item CostBreakdown
-		if err := rows.Scan(&item.Label, &item.RequestCount, &item.Cost, &item.AvgLatency, &item.ErrorCount); err != nil {
+		var orgID, count int
+		var provider, class string
+		if err := rows.Scan(&orgID, &provider, &class, &count); err != nil {
 			continue
 		}
-		breakdown = append(breakdown, item)
-		totalCost += item.Cost
-	}
-
-	// Cache in Redis for dashboard
-	data := map[string]interface{}{
-		"breakdown":  breakdown,
-		"total_cost": totalCost,
-		"updated_at": time.Now(),
+		if classes[orgID] == nil {
+			classes[orgID] = map[string]map[string]int{}
+		}
+		if classes[orgID][provider] == nil {
+			classes[orgID][provider] = map[string]int{}
+		}
+		classes[orgID][provider][class] += count
 	}
-	jsonData, _ := json.Marshal(data)
-	s.redis.Set(ctx, "costs:24h:by_provider", jsonData, 5*time.Minute)
-
-	log.Printf("Cost aggregation complete: $%.4f across %d providers", totalCost, len(breakdown))
+	return classes, rows.Err()
 }
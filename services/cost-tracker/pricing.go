@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/billing"
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+// Events may arrive this long after their billing month ends and still be
+// re-priced into it.
+const pricingGracePeriod = 72 * time.Hour
+
+type priceTier struct {
+	From     int64   `json:"from_requests"`
+	UnitCost float64 `json:"unit_cost"`
+}
+
+type providerPricing struct {
+	base       float64
+	free       int64
+	minimumFee float64
+	tiers      []priceTier // sorted by From
+}
+
+// unitCost is the list price of the n-th request of a billing period.
+func (p providerPricing) unitCost(n int64) float64 {
+	if n <= p.free {
+		return 0
+	}
+	unit := p.base
+	for _, t := range p.tiers {
+		if n > t.From {
+			unit = t.UnitCost
+		}
+	}
+	return unit
+}
+
+type commitment struct {
+	spend    float64
+	discount float64
+}
+
+type orgProvider struct {
+	orgID    int
+	provider string
+}
+
+type periodUsage struct {
+	requests int64
+	spend    float64
+}
+
+// PricingStatus is an organization's position in a provider's pricing for
+// the current billing month.
+type PricingStatus struct {
+	OrganizationID  int        `json:"organization_id"`
+	Provider        string     `json:"provider"`
+	PeriodStart     time.Time  `json:"period_start"`
+	Requests        int64      `json:"requests"`
+	Spend           float64    `json:"spend"`
+	UnitCost        float64    `json:"unit_cost"`
+	FreeRemaining   int64      `json:"free_remaining,omitempty"`
+	NextTier        *priceTier `json:"next_tier,omitempty"`
+	CommittedSpend  float64    `json:"committed_spend,omitempty"`
+	DiscountPercent float64    `json:"discount_percent,omitempty"`
+	MinimumFee      float64    `json:"minimum_fee,omitempty"`
+	Adjustments     float64    `json:"adjustments"` // minimum fee and commitment shortfall
+}
+
+// volumePriced reports whether a request's price depends on how many came
+// before it in the billing period.
+func (p providerPricing) volumePriced() bool {
+	return p.free > 0 || len(p.tiers) > 0
+}
+
+// loadPeriodPricing returns, for each provider, the price versions in effect
+// at any time in [start, end), oldest first.
+func (s *CostTrackerServer) loadPeriodPricing(ctx context.Context, start, end time.Time) (map[string][]*providerPricing, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, provider, base_cost_per_request, free_requests_per_period, minimum_monthly_fee
+        FROM price_versions v
+        WHERE effective_from < $2
+          AND NOT EXISTS (
+              SELECT 1 FROM price_versions n
+              WHERE n.provider = v.provider AND n.effective_from > v.effective_from AND n.effective_from <= $1
+          )
+        ORDER BY provider, effective_from
+    `, start, end)
+	if err != nil {
+		return nil, err
+	}
+	pricing := map[string][]*providerPricing{}
+	byVersion := map[int]*providerPricing{}
+	for rows.Next() {
+		var id int
+		var name string
+		p := &providerPricing{}
+		if err := rows.Scan(&id, &name, &p.base, &p.free, &p.minimumFee); err != nil {
+			continue
+		}
+		pricing[name] = append(pricing[name], p)
+		byVersion[id] = p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT price_version_id, from_requests, unit_cost FROM pricing_tiers ORDER BY price_version_id, from_requests`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var t priceTier
+		if err := rows.Scan(&id, &t.From, &t.UnitCost); err != nil {
+			continue
+		}
+		if p, ok := byVersion[id]; ok {
+			p.tiers = append(p.tiers, t)
+		}
+	}
+	return pricing, rows.Err()
+}
+
+// loadCommitments returns the commitments in force during any part of the
+// period, by organization and provider.
+func (s *CostTrackerServer) loadCommitments(ctx context.Context, start, end time.Time) (map[orgProvider]commitment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT organization_id, provider, MAX(committed_monthly_spend), MAX(discount_percent)
+        FROM pricing_commitments
+        WHERE starts_on < $2 AND (ends_on IS NULL OR ends_on > $1)
+        GROUP BY organization_id, provider
+    `, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commitments := map[orgProvider]commitment{}
+	for rows.Next() {
+		var key orgProvider
+		var c commitment
+		if err := rows.Scan(&key.orgID, &key.provider, &c.spend, &c.discount); err != nil {
+			continue
+		}
+		commitments[key] = c
+	}
+	return commitments, rows.Err()
+}
+
+func (s *CostTrackerServer) loadPeriodUsage(ctx context.Context, start, end time.Time) (map[orgProvider]periodUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT organization_id, provider, SUM(request_count), COALESCE(SUM(total_cost), 0)
+        FROM api_costs_hourly
+        WHERE bucket >= $1 AND bucket < $2
+        GROUP BY organization_id, provider
+    `, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := map[orgProvider]periodUsage{}
+	for rows.Next() {
+		var key orgProvider
+		var u periodUsage
+		if err := rows.Scan(&key.orgID, &key.provider, &u.requests, &u.spend); err != nil {
+			continue
+		}
+		usage[key] = u
+	}
+	return usage, rows.Err()
+}
+
+// repriceQuery recomputes the requests of an organization's billing month
+// ($3 to $4) that fall between $5 and $6. Each is priced from its position in
+// the month, the provider's price version in effect at the time, and the
+// commitment discount in force on the day, plus the size charge per KB ($7).
+// Only rows whose cost or price version changed are updated.
+const repriceQuery = `
+    UPDATE api_requests r
+    SET cost = o.new_cost, price_version_id = o.version_id
+    FROM (
+        SELECT
+            e.time,
+            e.request_id,
+            v.id AS version_id,
+            ROUND((
+                CASE WHEN e.n <= v.free_requests_per_period THEN 0
+                ELSE COALESCE(
+                    (SELECT t.unit_cost FROM pricing_tiers t
+                     WHERE t.price_version_id = v.id AND t.from_requests < e.n
+                     ORDER BY t.from_requests DESC LIMIT 1),
+                    v.base_cost_per_request
+                ) * (1 - COALESCE(
+                    (SELECT MAX(c.discount_percent) FROM pricing_commitments c
+                     WHERE c.organization_id = $1 AND c.provider = $2
+                       AND c.starts_on <= (e.time AT TIME ZONE 'UTC')::date
+                       AND (c.ends_on IS NULL OR c.ends_on > (e.time AT TIME ZONE 'UTC')::date)),
+                    0) / 100)
+                END
+                + (COALESCE(e.request_size_bytes, 0) + COALESCE(e.response_size_bytes, 0)) / 1024.0 * $7
+            )::numeric, 6) AS new_cost
+        FROM (
+            SELECT time, request_id, request_size_bytes, response_size_bytes,
+                   ROW_NUMBER() OVER (ORDER BY time, request_id) AS n
+            FROM api_requests
+            WHERE organization_id = $1 AND provider = $2 AND time >= $3 AND time < $4
+        ) e
+        JOIN LATERAL (
+            SELECT id, base_cost_per_request, free_requests_per_period
+            FROM price_versions
+            WHERE provider = $2 AND effective_from <= e.time
+            ORDER BY effective_from DESC
+            LIMIT 1
+        ) v ON TRUE
+        WHERE e.time >= $5 AND e.time < $6
+    ) o
+    WHERE r.organization_id = $1 AND r.provider = $2
+      AND r.time >= $5 AND r.time < $6
+      AND r.time = o.time AND r.request_id = o.request_id
+      AND (r.cost IS DISTINCT FROM o.new_cost OR r.price_version_id IS DISTINCT FROM o.version_id)
+`
+
+// repriceRange recomputes an organization's costs for a provider between
+// from and to, one billing month at a time so tier positions count the
+// whole month.
+func (s *CostTrackerServer) repriceRange(ctx context.Context, orgID int, provider string, from, to time.Time) (int64, error) {
+	var updated int64
+	month, _, _ := monthRange("", from)
+	for ; month.Before(to); month = month.AddDate(0, 1, 0) {
+		result, err := s.db.ExecContext(ctx, repriceQuery, orgID, provider, month, month.AddDate(0, 1, 0), from, to, billing.SizeCostPerKB)
+		if err != nil {
+			return updated, err
+		}
+		n, _ := result.RowsAffected()
+		updated += n
+	}
+	return updated, nil
+}
+
+// refreshAggregates re-materializes the hourly cost aggregates, tag totals
+// and rolling 24h totals after raw costs between from and to changed. Both
+// ends are widened to whole hours so no hour is refreshed in part; the open
+// hour is read from raw rows.
+func (s *CostTrackerServer) refreshAggregates(ctx context.Context, from, to time.Time) {
+	s.invalidateRollup(ctx, to)
+	if hour := to.UTC().Truncate(time.Hour); hour.Before(to) {
+		to = hour.Add(time.Hour)
+	}
+	if now := time.Now().UTC().Truncate(time.Hour); to.After(now) {
+		to = now
+	}
+	from = from.UTC().Truncate(time.Hour)
+	if !to.After(from) {
+		return
+	}
+	for _, view := range []string{"api_costs_hourly", "api_endpoint_costs_hourly"} {
+		// CALL doesn't take bind parameters
+		_, err := s.db.ExecContext(ctx, fmt.Sprintf("CALL refresh_continuous_aggregate('%s', '%s', '%s')",
+			view, from.Format(time.RFC3339), to.Format(time.RFC3339)))
+		if err != nil {
+			log.Printf("Failed to refresh %s: %v", view, err)
+		}
+	}
+	s.refreshTagCosts(ctx, from, to)
+}
+
+func (s *CostTrackerServer) trackPricing() {
+	interval := jobs.EnvDuration("PRICING_TRUEUP_INTERVAL", time.Hour)
+	s.jobs.Every("pricing_trueup", interval, interval, s.trueUpPricing)
+}
+
+// trueUpPricing re-prices the current billing month (and the previous one
+// during the grace period) so events priced before a tier boundary was
+// crossed, or before a commitment was recorded, carry the right cost. It
+// then records minimum fees and commitment shortfalls.
+func (s *CostTrackerServer) trueUpPricing(ctx context.Context) error {
+	now := time.Now().UTC()
+	current, _, _ := monthRange("", now)
+	periods := []time.Time{current}
+	if now.Sub(current) < pricingGracePeriod {
+		periods = append([]time.Time{current.AddDate(0, -1, 0)}, periods...)
+	}
+
+	statuses := []PricingStatus{}
+	for _, start := range periods {
+		end := start.AddDate(0, 1, 0)
+		// Versions take effect when recorded, so none past now applies yet
+		priced := end
+		if now.Before(priced) {
+			priced = now
+		}
+		versions, err := s.loadPeriodPricing(ctx, start, priced)
+		if err != nil {
+			return fmt.Errorf("load pricing for %s: %w", start.Format("2006-01"), err)
+		}
+		usage, err := s.loadPeriodUsage(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		commitments, err := s.loadCommitments(ctx, start, end)
+		if err != nil {
+			return err
+		}
+
+		var repriced int64
+		for key := range usage {
+			_, committed := commitments[key]
+			volumePriced := false
+			for _, p := range versions[key.provider] {
+				volumePriced = volumePriced || p.volumePriced()
+			}
+			if len(versions[key.provider]) == 0 || (!committed && !volumePriced) {
+				continue
+			}
+			n, err := s.repriceRange(ctx, key.orgID, key.provider, start, end)
+			if err != nil {
+				return fmt.Errorf("reprice %s for org %d: %w", key.provider, key.orgID, err)
+			}
+			repriced += n
+		}
+
+		if repriced > 0 {
+			log.Printf("Pricing true-up corrected %d requests in %s", repriced, start.Format("2006-01"))
+			s.refreshAggregates(ctx, start, end)
+			if usage, err = s.loadPeriodUsage(ctx, start, end); err != nil {
+				return err
+			}
+		}
+
+		for key := range commitments {
+			if _, ok := usage[key]; !ok {
+				usage[key] = periodUsage{}
+			}
+		}
+		for key, u := range usage {
+			st := PricingStatus{
+				OrganizationID: key.orgID,
+				Provider:       key.provider,
+				PeriodStart:    start,
+				Requests:       u.requests,
+				Spend:          u.spend,
+			}
+			c := commitments[key]
+			st.CommittedSpend, st.DiscountPercent = c.spend, c.discount
+
+			// A commitment is billed in full; a minimum fee tops up what's left.
+			// Both use the price version the period closes on.
+			shortfall := math.Max(0, c.spend-u.spend)
+			minimumFee := 0.0
+			if pv := versions[key.provider]; len(pv) > 0 {
+				p := pv[len(pv)-1]
+				if u.requests > 0 {
+					st.MinimumFee = p.minimumFee
+					minimumFee = math.Max(0, p.minimumFee-u.spend-shortfall)
+				}
+				st.UnitCost = p.unitCost(u.requests+1) * (1 - c.discount/100)
+				if u.requests < p.free {
+					st.FreeRemaining = p.free - u.requests
+				}
+				for i := range p.tiers {
+					if p.tiers[i].From > u.requests {
+						st.NextTier = &p.tiers[i]
+						break
+					}
+				}
+			}
+
+			final := !now.Before(end)
+			if err := s.recordAdjustment(ctx, key, start, "commitment_shortfall", shortfall, final); err != nil {
+				return err
+			}
+			if err := s.recordAdjustment(ctx, key, start, "minimum_fee", minimumFee, final); err != nil {
+				return err
+			}
+			st.Adjustments = shortfall + minimumFee
+
+			if start.Equal(current) {
+				statuses = append(statuses, st)
+			}
+		}
+	}
+
+	byOrg := map[int]interface{}{}
+	for _, st := range statuses {
+		list, _ := byOrg[st.OrganizationID].([]PricingStatus)
+		byOrg[st.OrganizationID] = append(list, st)
+	}
+	return s.orgs.Publish(ctx, "costs:pricing", byOrg, []PricingStatus{}, 3*jobs.EnvDuration("PRICING_TRUEUP_INTERVAL", time.Hour))
+}
+
+// recordAdjustment upserts a period charge, removing it once it no longer
+// applies.
+func (s *CostTrackerServer) recordAdjustment(ctx context.Context, key orgProvider, period time.Time, kind string, amount float64, final bool) error {
+	if amount <= 0 {
+		_, err := s.db.ExecContext(ctx, `
+            DELETE FROM cost_adjustments
+            WHERE organization_id = $1 AND provider = $2 AND period_start = $3 AND kind = $4
+        `, key.orgID, key.provider, period, kind)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+        INSERT INTO cost_adjustments (organization_id, provider, period_start, kind, amount, final, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NOW())
+        ON CONFLICT (organization_id, provider, period_start, kind)
+        DO UPDATE SET amount = EXCLUDED.amount, final = EXCLUDED.final, updated_at = NOW()
+    `, key.orgID, key.provider, period, kind, amount, final)
+	return err
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/yourusername/api-observatory/cost-tracker/proto"
+	"github.com/yourusername/api-observatory/shared/billing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultReconcileTolerance = 5.0 // percent
+	maxInvoiceSize            = 32 << 20
+	// Day-level differences smaller than this are rounding, not discrepancies.
+	minReconcileDifference = 0.01
+)
+
+// invoiceFormat describes one provider's billing or usage export. Column
+// names are candidates, matched case-insensitively against the header.
+type invoiceFormat struct {
+	date   []string
+	amount []string
+	count  []string // optional billed request counts
+	// category and totals select the summary rows of exports that also list
+	// each sub-category, so usage isn't counted twice.
+	category string
+	totals   string
+	skip     func(row map[string]string) bool
+}
+
+var invoiceFormats = map[string]invoiceFormat{
+	// Usage/cost export from the OpenAI dashboard or the costs API
+	"OpenAI": {
+		date:   []string{"date", "start_time", "usage_date"},
+		amount: []string{"cost", "amount_value", "cost_usd", "amount"},
+		count:  []string{"n_requests", "num_model_requests", "requests"},
+	},
+	// Balance transactions export; API spend is the Fee column
+	"Stripe": {
+		date:   []string{"created (utc)", "created", "created_utc"},
+		amount: []string{"fee"},
+		skip: func(row map[string]string) bool {
+			switch strings.ToLower(row["type"]) {
+			case "payout", "transfer":
+				return true
+			}
+			return false
+		},
+	},
+	// Usage records export (daily), one row per category
+	"Twilio": {
+		date:     []string{"start date", "start_date", "startdate"},
+		amount:   []string{"price"},
+		count:    []string{"count"},
+		category: "category",
+		totals:   "totalprice",
+	},
+}
+
+// genericInvoiceFormat is used for providers without a dedicated parser.
+var genericInvoiceFormat = invoiceFormat{
+	date:   []string{"date", "day"},
+	amount: []string{"cost", "amount", "total"},
+	count:  []string{"requests", "count"},
+}
+
+var invoiceDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"01/02/2006",
+	"20060102",
+}
+
+// minInvoiceEpoch is the earliest Unix time accepted as an invoice date,
+// 2000-01-01. Smaller numbers are not plausible billing dates.
+const minInvoiceEpoch = 946684800
+
+type invoiceDay struct {
+	cost     float64
+	requests int64
+	counted  bool // the export reported a request count for the day
+}
+
+type invoice struct {
+	provider string
+	days     map[time.Time]*invoiceDay
+	parsed   int
+	skipped  int
+}
+
+// invoiceProvider returns the provider's canonical name and export format.
+func invoiceProvider(provider string) (string, invoiceFormat) {
+	for name, format := range invoiceFormats {
+		if strings.EqualFold(name, provider) {
+			return name, format
+		}
+	}
+	return provider, genericInvoiceFormat
+}
+
+// parseInvoice totals a provider's CSV export by UTC day.
+func parseInvoice(provider string, r io.Reader) (*invoice, error) {
+	name, format := invoiceProvider(provider)
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	find := func(candidates []string) string {
+		for _, c := range candidates {
+			for _, h := range header {
+				if h == c {
+					return h
+				}
+			}
+		}
+		return ""
+	}
+	dateCol, amountCol, countCol := find(format.date), find(format.amount), find(format.count)
+	if dateCol == "" || amountCol == "" {
+		return nil, fmt.Errorf("%s export needs a date column (%s) and an amount column (%s)",
+			name, strings.Join(format.date, ", "), strings.Join(format.amount, ", "))
+	}
+
+	records := []map[string]string{}
+	hasTotals := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		if format.totals != "" && strings.EqualFold(row[format.category], format.totals) {
+			hasTotals = true
+		}
+		records = append(records, row)
+	}
+
+	// Summary rows carry cost only; their counts mix units across categories
+	if hasTotals {
+		countCol = ""
+	}
+
+	inv := &invoice{provider: name, days: map[time.Time]*invoiceDay{}}
+	for _, row := range records {
+		if hasTotals && !strings.EqualFold(row[format.category], format.totals) {
+			continue
+		}
+		if format.skip != nil && format.skip(row) {
+			inv.skipped++
+			continue
+		}
+		day, err := parseInvoiceDate(row[dateCol])
+		if err != nil {
+			inv.skipped++
+			continue
+		}
+		amount, err := parseInvoiceAmount(row[amountCol])
+		if err != nil {
+			inv.skipped++
+			continue
+		}
+
+		d, ok := inv.days[day]
+		if !ok {
+			d = &invoiceDay{}
+			inv.days[day] = d
+		}
+		d.cost += amount
+		if countCol != "" {
+			if n, err := parseInvoiceAmount(row[countCol]); err == nil {
+				d.requests += int64(n)
+				d.counted = true
+			}
+		}
+		inv.parsed++
+	}
+
+	if len(inv.days) == 0 {
+		return nil, errors.New("no billable rows found")
+	}
+	return inv, nil
+}
+
+// parseInvoiceDate tries the date layouts first, so a compact date like
+// 20240115 is never read as Unix seconds. Plain numbers are only accepted
+// as Unix seconds from minInvoiceEpoch on.
+func parseInvoiceDate(value string) (time.Time, error) {
+	for _, layout := range invoiceDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Truncate(24 * time.Hour), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil && secs >= minInvoiceEpoch {
+		return time.Unix(secs, 0).UTC().Truncate(24 * time.Hour), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+func parseInvoiceAmount(value string) (float64, error) {
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	return strconv.ParseFloat(value, 64)
+}
+
+type trackedDay struct {
+	cost     float64
+	requests int64
+	sizeCost float64 // portion of cost charged for payload size
+}
+
+func (s *CostTrackerServer) loadTrackedDays(ctx context.Context, orgID int, provider string, start, end time.Time) (map[time.Time]trackedDay, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT
+            time_bucket('1 day', time) as day,
+            COUNT(*),
+            COALESCE(SUM(cost), 0),
+            COALESCE(SUM(COALESCE(request_size_bytes, 0) + COALESCE(response_size_bytes, 0)), 0) / 1024.0 * $5
+        FROM api_requests
+        WHERE organization_id = $1 AND provider = $2 AND time >= $3 AND time < $4
+        GROUP BY day
+    `, orgID, provider, start, end, billing.SizeCostPerKB)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := map[time.Time]trackedDay{}
+	for rows.Next() {
+		var day time.Time
+		var t trackedDay
+		if err := rows.Scan(&day, &t.requests, &t.cost, &t.sizeCost); err != nil {
+			continue
+		}
+		days[day.UTC()] = t
+	}
+	return days, rows.Err()
+}
+
+// reconcile compares an invoice day by day against tracked api_requests.cost
+// and suggests catalog corrections when the totals disagree.
+func (s *CostTrackerServer) reconcile(ctx context.Context, orgID int, inv *invoice, tolerance float64) (*pb.ReconciliationReport, error) {
+	dates := []time.Time{}
+	for day := range inv.days {
+		dates = append(dates, day)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	start, end := dates[0], dates[len(dates)-1].Add(24*time.Hour)
+
+	tracked, err := s.loadTrackedDays(ctx, orgID, inv.provider, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &pb.ReconciliationReport{
+		Provider:    inv.provider,
+		RowsParsed:  int32(inv.parsed),
+		RowsSkipped: int32(inv.skipped),
+	}
+	var trackedRequests, invoiceRequests int64
+	var sizeCost float64
+	counted, missingDays := true, 0
+
+	for day := start; day.Before(end); day = day.Add(24 * time.Hour) {
+		billed, onInvoice := inv.days[day]
+		if !onInvoice {
+			billed = &invoiceDay{}
+		}
+		t := tracked[day]
+
+		d := &pb.ReconciliationDay{
+			Date:            day.Format("2006-01-02"),
+			InvoiceCost:     billed.cost,
+			TrackedCost:     t.cost,
+			Difference:      billed.cost - t.cost,
+			InvoiceRequests: billed.requests,
+			TrackedRequests: t.requests,
+		}
+		d.DifferencePercent = percentDifference(billed.cost, t.cost)
+		d.Flagged = math.Abs(d.Difference) >= minReconcileDifference && math.Abs(d.DifferencePercent) > tolerance
+
+		switch {
+		case !onInvoice && t.requests > 0:
+			d.Note = "tracked traffic missing from the invoice"
+		case t.requests == 0 && billed.cost > 0:
+			d.Note = "invoiced but no traffic tracked"
+			missingDays++
+		case billed.counted && math.Abs(percentDifference(float64(billed.requests), float64(t.requests))) > tolerance:
+			d.Note = fmt.Sprintf("invoice bills %d requests, %d tracked", billed.requests, t.requests)
+		}
+		if d.Flagged {
+			report.FlaggedDays++
+		}
+		report.Days = append(report.Days, d)
+
+		report.InvoiceTotal += billed.cost
+		report.TrackedTotal += t.cost
+		trackedRequests += t.requests
+		invoiceRequests += billed.requests
+		sizeCost += t.sizeCost
+		if onInvoice && !billed.counted {
+			counted = false
+		}
+	}
+	report.Difference = report.InvoiceTotal - report.TrackedTotal
+	report.DifferencePercent = percentDifference(report.InvoiceTotal, report.TrackedTotal)
+
+	if missingDays > 0 {
+		report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+			"%d invoiced days have no tracked %s traffic; check that every client reports calls to the observatory.",
+			missingDays, inv.provider))
+	}
+	if counted && invoiceRequests > 0 && math.Abs(percentDifference(float64(invoiceRequests), float64(trackedRequests))) > tolerance {
+		report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+			"The invoice bills %d requests but %d were tracked, so part of the gap is missing or extra traffic rather than pricing.",
+			invoiceRequests, trackedRequests))
+	}
+
+	if math.Abs(report.DifferencePercent) > tolerance && trackedRequests > 0 {
+		discrepancy, err := s.priceDiscrepancy(ctx, inv.provider, start, report.InvoiceTotal-sizeCost, trackedRequests, tolerance)
+		if err != nil {
+			log.Printf("Failed to load %s pricing for reconciliation: %v", inv.provider, err)
+		} else if discrepancy != nil {
+			report.PriceDiscrepancy = discrepancy
+			report.Suggestions = append(report.Suggestions, fmt.Sprintf(
+				"The invoice implies $%.6f per request, %+.0f%% from price version %d. Check whether your contract rate differs from the tracked price.",
+				discrepancy.ImpliedUnitPrice, discrepancy.DifferencePercent, discrepancy.PriceVersionId))
+		}
+	}
+	return report, nil
+}
+
+// priceDiscrepancy compares the per-request price implied by the invoice
+// with the price version in effect at its start. It returns nil when they
+// agree within tolerance or the provider has no price version.
+func (s *CostTrackerServer) priceDiscrepancy(ctx context.Context, provider string, since time.Time, requestCost float64, requests int64, tolerance float64) (*pb.PriceDiscrepancy, error) {
+	d := &pb.PriceDiscrepancy{Provider: provider}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT id, base_cost_per_request,
+               EXISTS (SELECT 1 FROM pricing_tiers WHERE price_version_id = v.id)
+        FROM price_versions v
+        WHERE provider = $1 AND effective_from <= $2
+        ORDER BY effective_from DESC
+        LIMIT 1
+    `, provider, since).Scan(&d.PriceVersionId, &d.TrackedUnitPrice, &d.Tiered)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.ImpliedUnitPrice = requestCost / float64(requests)
+	d.DifferencePercent = percentDifference(d.ImpliedUnitPrice, d.TrackedUnitPrice)
+	if d.ImpliedUnitPrice <= 0 || d.TrackedUnitPrice <= 0 || math.Abs(d.DifferencePercent) <= tolerance {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// percentDifference returns how far actual is from expected, in percent.
+func percentDifference(actual, expected float64) float64 {
+	if expected == 0 {
+		if actual == 0 {
+			return 0
+		}
+		return 100
+	}
+	return 100 * (actual - expected) / expected
+}
+
+// ReconcileInvoice reconciles an uploaded provider CSV export.
+func (c *costService) ReconcileInvoice(ctx context.Context, req *pb.InvoiceRequest) (*pb.ReconciliationReport, error) {
+	orgID, err := strconv.Atoi(req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid organization_id %q", req.OrganizationId)
+	}
+	if req.Provider == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider is required")
+	}
+	tolerance := req.TolerancePercent
+	if tolerance <= 0 {
+		tolerance = defaultReconcileTolerance
+	}
+
+	inv, err := parseInvoice(req.Provider, bytes.NewReader(req.Csv))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s export: %v", req.Provider, err)
+	}
+
+	report, err := c.server.reconcile(ctx, orgID, inv, tolerance)
+	if err != nil {
+		log.Printf("Reconciliation of %s invoice for org %d failed: %v", inv.provider, orgID, err)
+		return nil, status.Error(codes.Internal, "failed to reconcile invoice")
+	}
+	log.Printf("Reconciled %s invoice for org %d: $%.2f invoiced vs $%.2f tracked, %d days flagged",
+		inv.provider, orgID, report.InvoiceTotal, report.TrackedTotal, report.FlaggedDays)
+	return report, nil
+}
+
+// runReconcile implements `cost-tracker-service reconcile`. It returns the
+// process exit code.
+func (s *CostTrackerServer) runReconcile(args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	orgID := fs.Int("org", 1, "Organization ID")
+	provider := fs.String("provider", "", "Provider whose export is being reconciled (OpenAI, Stripe, Twilio, ...)")
+	file := fs.String("file", "", "CSV export to reconcile")
+	tolerance := fs.Float64("tolerance", defaultReconcileTolerance, "Percent difference tolerated before a day is flagged")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *provider == "" || *file == "" {
+		fmt.Fprintln(os.Stderr, "-provider and -file are required")
+		return 2
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open %s: %v\n", *file, err)
+		return 2
+	}
+	defer f.Close()
+
+	inv, err := parseInvoice(*provider, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s export: %v\n", *provider, err)
+		return 2
+	}
+	report, err := s.reconcile(context.Background(), *orgID, inv, *tolerance)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reconciliation failed: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		data, _ := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(report)
+		fmt.Println(string(data))
+		return 0
+	}
+
+	fmt.Printf("%s invoice, organization %d: %d rows parsed, %d skipped\n\n", report.Provider, *orgID, report.RowsParsed, report.RowsSkipped)
+	fmt.Printf("%-10s  %12s  %12s  %12s  %8s\n", "date", "invoiced", "tracked", "difference", "")
+	for _, d := range report.Days {
+		marker := ""
+		if d.Flagged {
+			marker = fmt.Sprintf("%+.1f%%", d.DifferencePercent)
+		}
+		fmt.Printf("%-10s  %12.4f  %12.4f  %12.4f  %8s  %s\n", d.Date, d.InvoiceCost, d.TrackedCost, d.Difference, marker, d.Note)
+	}
+	fmt.Printf("%-10s  %12.4f  %12.4f  %12.4f  %+7.1f%%\n\n", "total", report.InvoiceTotal, report.TrackedTotal, report.Difference, report.DifferencePercent)
+	fmt.Printf("%d of %d days differ by more than %.1f%%\n", report.FlaggedDays, len(report.Days), *tolerance)
+	for _, suggestion := range report.Suggestions {
+		fmt.Printf("- %s\n", suggestion)
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInvoice(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		csv      string
+		want     map[time.Time]invoiceDay
+		skipped  int
+		wantErr  bool
+	}{
+		{
+			name:     "generic export",
+			provider: "Acme",
+			csv:      "date,cost,requests\n2024-01-15,1.50,100\n2024-01-15,\"$2,000.50\",50\n2024-01-16,1,10\n",
+			want: map[time.Time]invoiceDay{
+				day("2024-01-15"): {cost: 2002, requests: 150, counted: true},
+				day("2024-01-16"): {cost: 1, requests: 10, counted: true},
+			},
+		},
+		{
+			name:     "header with BOM and mixed case",
+			provider: "acme",
+			csv:      "\ufeffDate, Amount\n20240115,3\n",
+			want:     map[time.Time]invoiceDay{day("2024-01-15"): {cost: 3}},
+		},
+		{
+			name:     "unparseable rows are skipped",
+			provider: "Acme",
+			csv:      "date,cost\n2024-01-15,1\nyesterday,2\n2024-01-15,n/a\n12345,4\n",
+			want:     map[time.Time]invoiceDay{day("2024-01-15"): {cost: 1}},
+			skipped:  3,
+		},
+		{
+			name:     "openai usage export",
+			provider: "openai",
+			csv:      "start_time,cost_usd,num_model_requests\n1705312800,0.75,30\n2024-01-15T23:30:00-05:00,0.25,10\n",
+			want: map[time.Time]invoiceDay{
+				day("2024-01-15"): {cost: 0.75, requests: 30, counted: true},
+				day("2024-01-16"): {cost: 0.25, requests: 10, counted: true},
+			},
+		},
+		{
+			name:     "stripe payouts are not fees",
+			provider: "Stripe",
+			csv:      "Created (UTC),Type,Fee\n2024-01-15 10:00,charge,0.59\n2024-01-15 11:00,payout,0.25\n2024-01-15 12:00,charge,0.30\n",
+			want:     map[time.Time]invoiceDay{day("2024-01-15"): {cost: 0.89}},
+			skipped:  1,
+		},
+		{
+			name:     "twilio totals rows only",
+			provider: "Twilio",
+			csv:      "Category,Start Date,Count,Price\nsms,2024-01-15,100,0.79\ncalls,2024-01-15,20,1.20\ntotalprice,2024-01-15,120,1.99\n",
+			want:     map[time.Time]invoiceDay{day("2024-01-15"): {cost: 1.99}},
+		},
+		{
+			name:     "twilio without totals rows",
+			provider: "Twilio",
+			csv:      "Category,Start Date,Count,Price\nsms,2024-01-15,100,0.79\ncalls,2024-01-15,20,1.20\n",
+			want:     map[time.Time]invoiceDay{day("2024-01-15"): {cost: 1.99, requests: 120, counted: true}},
+		},
+		{name: "missing amount column", provider: "Acme", csv: "date,requests\n2024-01-15,1\n", wantErr: true},
+		{name: "no billable rows", provider: "Acme", csv: "date,cost\nbad,1\n", wantErr: true},
+		{name: "empty file", provider: "Acme", csv: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, err := parseInvoice(tt.provider, strings.NewReader(tt.csv))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseInvoice() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseInvoice() failed: %v", err)
+			}
+			if inv.skipped != tt.skipped {
+				t.Errorf("skipped %d rows, want %d", inv.skipped, tt.skipped)
+			}
+			if len(inv.days) != len(tt.want) {
+				t.Fatalf("parsed %d days, want %d", len(inv.days), len(tt.want))
+			}
+			for d, want := range tt.want {
+				got, ok := inv.days[d]
+				if !ok {
+					t.Errorf("missing day %s", d.Format("2006-01-02"))
+					continue
+				}
+				if math.Abs(got.cost-want.cost) > 1e-9 || got.requests != want.requests || got.counted != want.counted {
+					t.Errorf("day %s = %+v, want %+v", d.Format("2006-01-02"), *got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvoiceDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // "" when the value is rejected
+	}{
+		{"2024-01-15", "2024-01-15"},
+		{"2024-01-15 23:59:59", "2024-01-15"},
+		{"2024-01-15T23:30:00-05:00", "2024-01-16"},
+		{"01/15/2024", "2024-01-15"},
+		{"20240115", "2024-01-15"},
+		{"1705312800", "2024-01-15"},
+		{"12345", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := parseInvoiceDate(tt.value)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("parseInvoiceDate(%q) = %s, want an error", tt.value, got)
+		case tt.want != "" && err != nil:
+			t.Errorf("parseInvoiceDate(%q) failed: %v", tt.value, err)
+		case tt.want != "" && got.Format("2006-01-02") != tt.want:
+			t.Errorf("parseInvoiceDate(%q) = %s, want %s", tt.value, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
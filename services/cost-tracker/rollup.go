@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+const (
+	// rollupLateness matches the ingestion service: a minute's bucket stops
+	// changing once it is this far in the past.
+	rollupLateness = 2 * time.Minute
+
+	rollupWindowMinutes = 24 * 60
+	rollupTotalsKey     = "rollup:24h"
+	rollupCursorKey     = "rollup:24h:cursor"
+	// rollupGenerationKey is bumped on every invalidation, so a save based
+	// on totals read before it fails and is retried.
+	rollupGenerationKey = "rollup:24h:generation"
+	rollupSaveAttempts  = 3
+
+	// Repricing anything newer than this may change the rolling window.
+	rollupRepriceHorizon = 25 * time.Hour
+)
+
+// rollupTotals holds the rolling 24h sums under the ingestion service's
+// "<org>|<provider>|<metric>" fields, up to and including minute cursor.
+type rollupTotals struct {
+	cursor int64
+	fields map[string]float64
+}
+
+func rollupBucketKey(minute int64) string {
+	return fmt.Sprintf("rollup:1m:%d", minute)
+}
+
+// settledMinute is the last minute whose bucket no longer takes late events.
+func settledMinute(now time.Time) int64 {
+	return now.Add(-rollupLateness).Unix()/60 - 1
+}
+
+func (s *CostTrackerServer) checkCostRollup() {
+	interval := jobs.EnvDuration("ROLLUP_CHECK_INTERVAL", time.Hour)
+	s.jobs.Every("cost_rollup_check", interval, jobs.EnvDuration("ROLLUP_CHECK_TIMEOUT", 10*time.Minute), s.verifyCostRollup)
+}
+
+// loadRollup reads the totals and their cursor. It returns nil when there
+// is no rolling window yet. Callers read inside updateRollup, whose watch
+// makes the two reads consistent.
+func loadRollup(ctx context.Context, tx *redis.Tx) (*rollupTotals, error) {
+	n, err := tx.Get(ctx, rollupCursorKey).Int64()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields, err := tx.HGetAll(ctx, rollupTotalsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	return &rollupTotals{cursor: n, fields: parseRollupFields(fields)}, nil
+}
+
+func saveRollup(ctx context.Context, pipe redis.Pipeliner, t *rollupTotals) {
+	values := map[string]interface{}{}
+	for field, v := range t.fields {
+		values[field] = v
+	}
+
+	pipe.Del(ctx, rollupTotalsKey)
+	if len(values) > 0 {
+		pipe.HSet(ctx, rollupTotalsKey, values)
+	}
+	pipe.Set(ctx, rollupCursorKey, t.cursor, 0)
+}
+
+// updateRollup passes the stored window (nil if there is none) to fn and
+// saves the totals fn returns, unless it returns nil. If the window is
+// invalidated or saved elsewhere in the meantime, the save is dropped and
+// fn runs again on the new state. It returns the totals saved, or the
+// stored ones when fn saved nothing.
+func (s *CostTrackerServer) updateRollup(ctx context.Context, fn func(t *rollupTotals) (*rollupTotals, error)) (*rollupTotals, error) {
+	for attempt := 0; attempt < rollupSaveAttempts; attempt++ {
+		var result *rollupTotals
+		err := s.redis.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := loadRollup(ctx, tx)
+			if err != nil {
+				return err
+			}
+			next, err := fn(current)
+			if err != nil {
+				return err
+			}
+			if next == nil {
+				result = current
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				saveRollup(ctx, pipe, next)
+				return nil
+			})
+			result = next
+			return err
+		}, rollupCursorKey, rollupGenerationKey)
+		if err != redis.TxFailedErr {
+			return result, err
+		}
+	}
+	return nil, fmt.Errorf("24h cost rollup changed during %d attempts to save it", rollupSaveAttempts)
+}
+
+func parseRollupFields(raw map[string]string) map[string]float64 {
+	fields := make(map[string]float64, len(raw))
+	for field, value := range raw {
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[field] = v
+		}
+	}
+	return fields
+}
+
+// advance moves the window forward to minute to, adding each new minute's
+// bucket and subtracting the minutes that fall out of the 24h window. The
+// expiring minutes are read back from api_requests rather than their
+// buckets, so late events and repricing picked up by a recompute are taken
+// out again at the same value they were counted at.
+func (s *CostTrackerServer) advance(ctx context.Context, t *rollupTotals, to int64) error {
+	if to <= t.cursor {
+		return nil
+	}
+
+	pipe := s.redis.Pipeline()
+	added := []*redis.StringStringMapCmd{}
+	for m := t.cursor + 1; m <= to; m++ {
+		added = append(added, pipe.HGetAll(ctx, rollupBucketKey(m)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+	expired, err := s.windowFields(ctx, t.cursor+1-rollupWindowMinutes, to-rollupWindowMinutes)
+	if err != nil {
+		return err
+	}
+
+	buckets := make([]map[string]float64, len(added))
+	for i := range added {
+		buckets[i] = parseRollupFields(added[i].Val())
+	}
+	t.shift(to, buckets, expired)
+	return nil
+}
+
+// shift moves the cursor to minute to, adding the new minutes' buckets and
+// subtracting the totals of the minutes that expired. Fields that cancel
+// out are dropped.
+func (t *rollupTotals) shift(to int64, added []map[string]float64, expired map[string]float64) {
+	for _, bucket := range added {
+		for field, v := range bucket {
+			t.fields[field] += v
+		}
+	}
+	for field, v := range expired {
+		t.fields[field] -= v
+	}
+	for field, v := range t.fields {
+		if math.Abs(v) < 1e-9 {
+			delete(t.fields, field)
+		}
+	}
+	t.cursor = to
+}
+
+// recomputeRollup rebuilds the totals for the 24h ending at minute cursor
+// from api_requests.
+func (s *CostTrackerServer) recomputeRollup(ctx context.Context, cursor int64) (*rollupTotals, error) {
+	fields, err := s.windowFields(ctx, cursor-rollupWindowMinutes+1, cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &rollupTotals{cursor: cursor, fields: fields}, nil
+}
+
+// windowFields sums api_requests for minutes first through last, inclusive,
+// into rollup fields.
+func (s *CostTrackerServer) windowFields(ctx context.Context, first, last int64) (map[string]float64, error) {
+	start := time.Unix(first*60, 0)
+	end := time.Unix((last+1)*60, 0)
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT
+            organization_id,
+            provider,
+            COUNT(*),
+            COALESCE(SUM(cost), 0),
+            COALESCE(SUM(latency_ms), 0),
+            COUNT(CASE WHEN status_code >= 400 THEN 1 END)
+        FROM api_requests
+        WHERE time >= $1 AND time < $2
+        GROUP BY organization_id, provider
+    `, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := map[string]float64{}
+	for rows.Next() {
+		var orgID int
+		var provider string
+		var requests, latency, failed, cost float64
+		if err := rows.Scan(&orgID, &provider, &requests, &cost, &latency, &failed); err != nil {
+			continue
+		}
+		prefix := fmt.Sprintf("%d|%s|", orgID, provider)
+		fields[prefix+"requests"] = requests
+		fields[prefix+"cost"] = cost
+		fields[prefix+"latency_ms"] = latency
+		if failed > 0 {
+			fields[prefix+"errors"] = failed
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	classes, err := s.errorClassesByProvider(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for orgID, providers := range classes {
+		for provider, counts := range providers {
+			for class, n := range counts {
+				fields[fmt.Sprintf("%d|%s|class:%s", orgID, provider, class)] = float64(n)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// invalidateRollup drops the stored window when repricing touched requests
+// from the last 24 hours, so the next read rebuilds it at the new costs
+// instead of waiting for the hourly check.
+func (s *CostTrackerServer) invalidateRollup(ctx context.Context, to time.Time) {
+	if !to.After(time.Now().Add(-rollupRepriceHorizon)) {
+		return
+	}
+	pipe := s.redis.TxPipeline()
+	pipe.Incr(ctx, rollupGenerationKey)
+	pipe.Del(ctx, rollupCursorKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to invalidate the 24h cost rollup: %v", err)
+	}
+}
+
+// verifyCostRollup is the consistency check for the incremental totals: it
+// recomputes the window from api_requests, logs any drift and replaces the
+// totals. Requests ingested too late for their minute's bucket, or inserted
+// without going through ingestion, are added here; advance subtracts them
+// again when their minute expires.
+func (s *CostTrackerServer) verifyCostRollup(ctx context.Context) error {
+	cursor := settledMinute(time.Now())
+	_, err := s.updateRollup(ctx, func(current *rollupTotals) (*rollupTotals, error) {
+		fresh, err := s.recomputeRollup(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && current.cursor <= cursor && cursor-current.cursor < rollupWindowMinutes {
+			if err := s.advance(ctx, current, cursor); err != nil {
+				return nil, err
+			}
+			drift := fresh.sum("|cost") - current.sum("|cost")
+			requests := fresh.sum("|requests") - current.sum("|requests")
+			if math.Abs(drift) > 0.0001 || math.Abs(requests) >= 0.5 {
+				log.Printf("Cost rollup drifted by $%.4f and %.0f requests; replacing with a full recompute", drift, requests)
+			}
+		}
+		return fresh, nil
+	})
+	return err
+}
+
+// rollingCosts returns the last 24 hours of cost totals. Settled minutes are
+// folded into the stored totals; the minutes since are added on top
+// without being saved, since they can still change.
+func (s *CostTrackerServer) rollingCosts(ctx context.Context, now time.Time) (*rollupTotals, error) {
+	settled := settledMinute(now)
+
+	t, err := s.updateRollup(ctx, func(t *rollupTotals) (*rollupTotals, error) {
+		switch {
+		case t == nil || settled-t.cursor >= rollupWindowMinutes:
+			// No usable window (first run, or the buckets have expired)
+			log.Printf("Rebuilding the 24h cost rollup from api_requests")
+			return s.recomputeRollup(ctx, settled)
+		case t.cursor < settled:
+			if err := s.advance(ctx, t, settled); err != nil {
+				return nil, err
+			}
+			return t, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.advance(ctx, t, now.Unix()/60); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// sum adds up one metric across every organization and provider.
+func (t *rollupTotals) sum(suffix string) float64 {
+	total := 0.0
+	for field, v := range t.fields {
+		if strings.HasSuffix(field, suffix) {
+			total += v
+		}
+	}
+	return total
+}
+
+// breakdowns turns rolling totals into each organization's per-provider
+// breakdown, most expensive first.
+func (t *rollupTotals) breakdowns() map[int][]CostBreakdown {
+	type groupKey struct {
+		orgID    int
+		provider string
+	}
+	groups := map[groupKey]*CostBreakdown{}
+	latency := map[groupKey]float64{}
+
+	for field, v := range t.fields {
+		parts := strings.SplitN(field, "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		orgID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		key := groupKey{orgID, parts[1]}
+		item := groups[key]
+		if item == nil {
+			item = &CostBreakdown{Label: parts[1]}
+			groups[key] = item
+		}
+
+		switch metric := parts[2]; {
+		case metric == "requests":
+			item.RequestCount = int64(math.Round(v))
+		case metric == "cost":
+			item.Cost = v
+		case metric == "latency_ms":
+			latency[key] = v
+		case metric == "errors":
+			item.ErrorCount = int(math.Round(v))
+		case strings.HasPrefix(metric, "class:"):
+			if n := int(math.Round(v)); n > 0 {
+				if item.ErrorClasses == nil {
+					item.ErrorClasses = map[string]int{}
+				}
+				item.ErrorClasses[strings.TrimPrefix(metric, "class:")] = n
+			}
+		}
+	}
+
+	result := map[int][]CostBreakdown{}
+	for key, item := range groups {
+		if item.RequestCount <= 0 {
+			continue
+		}
+		item.AvgLatency = latency[key] / float64(item.RequestCount)
+		result[key.orgID] = append(result[key.orgID], *item)
+	}
+	for _, items := range result {
+		sort.Slice(items, func(i, j int) bool { return items[i].Cost > items[j].Cost })
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRollupShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]float64
+		added    []map[string]float64
+		expired  map[string]float64
+		expected map[string]float64
+	}{
+		{
+			"nothing changes",
+			map[string]float64{"1|Stripe|cost": 2},
+			nil,
+			nil,
+			map[string]float64{"1|Stripe|cost": 2},
+		},
+		{
+			"new minutes are added",
+			map[string]float64{"1|Stripe|cost": 2, "1|Stripe|requests": 20},
+			[]map[string]float64{
+				{"1|Stripe|cost": 0.5, "1|Stripe|requests": 5},
+				{"1|Stripe|cost": 0.25, "1|Stripe|requests": 3, "2|Twilio|requests": 1},
+			},
+			nil,
+			map[string]float64{"1|Stripe|cost": 2.75, "1|Stripe|requests": 28, "2|Twilio|requests": 1},
+		},
+		{
+			"expired minutes are subtracted",
+			map[string]float64{"1|Stripe|cost": 2, "1|Stripe|requests": 20},
+			nil,
+			map[string]float64{"1|Stripe|cost": 0.5, "1|Stripe|requests": 4},
+			map[string]float64{"1|Stripe|cost": 1.5, "1|Stripe|requests": 16},
+		},
+		{
+			"added and expired in one step",
+			map[string]float64{"1|Stripe|cost": 2, "1|Stripe|errors": 1},
+			[]map[string]float64{{"1|Stripe|cost": 1, "1|Stripe|class:rate_limit": 2}},
+			map[string]float64{"1|Stripe|cost": 0.5, "1|Stripe|errors": 1},
+			map[string]float64{"1|Stripe|cost": 2.5, "1|Stripe|class:rate_limit": 2},
+		},
+		{
+			"fields that cancel out are dropped",
+			map[string]float64{"1|Stripe|cost": 0.3, "2|Twilio|cost": 1},
+			nil,
+			map[string]float64{"1|Stripe|cost": 0.1 + 0.2},
+			map[string]float64{"2|Twilio|cost": 1},
+		},
+		{
+			"an organization's whole window expires",
+			map[string]float64{"1|Stripe|cost": 1, "1|Stripe|requests": 10},
+			[]map[string]float64{{"2|Stripe|requests": 1}},
+			map[string]float64{"1|Stripe|cost": 1, "1|Stripe|requests": 10},
+			map[string]float64{"2|Stripe|requests": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := &rollupTotals{cursor: 100, fields: tt.fields}
+			totals.shift(105, tt.added, tt.expired)
+			if totals.cursor != 105 {
+				t.Errorf("cursor = %d, want 105", totals.cursor)
+			}
+			if len(totals.fields) != len(tt.expected) {
+				t.Fatalf("fields = %v, want %v", totals.fields, tt.expected)
+			}
+			for field, want := range tt.expected {
+				got, ok := totals.fields[field]
+				if !ok || math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", field, got, want)
+				}
+			}
+		})
+	}
+}
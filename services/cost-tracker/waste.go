@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/jobs"
+)
+
+const (
+	defaultWasteWindows = "1h,24h,7d"
+	// Items listed per window, most expensive first.
+	maxWasteItems = 25
+)
+
+// WasteItem is the spend on failed calls for one provider, endpoint and
+// error class. Billable failures are charged by the provider; for the
+// others the stored cost overstates what was actually paid.
+type WasteItem struct {
+	Provider   string  `json:"provider"`
+	Endpoint   string  `json:"endpoint"`
+	ErrorClass string  `json:"error_class"`
+	Billable   bool    `json:"billable"`
+	Requests   int64   `json:"requests"`
+	Cost       float64 `json:"cost"`
+}
+
+// ProviderWaste totals a provider's failed calls; the top fields name its
+// most expensive endpoint and error class.
+type ProviderWaste struct {
+	Provider      string  `json:"provider"`
+	Billable      bool    `json:"billable"`
+	Requests      int64   `json:"requests"`
+	Cost          float64 `json:"cost"`
+	TopEndpoint   string  `json:"top_endpoint"`
+	TopErrorClass string  `json:"top_error_class"`
+}
+
+type WasteWindow struct {
+	Window          string           `json:"window"`
+	FailedRequests  int64            `json:"failed_requests"`
+	FailedCost      float64          `json:"failed_cost"`
+	BillableCost    float64          `json:"billable_cost"`
+	NonBillableCost float64          `json:"non_billable_cost"`
+	TotalCost       float64          `json:"total_cost"`
+	ShareOfSpend    float64          `json:"share_of_spend"`
+	Providers       []*ProviderWaste `json:"providers"`
+	Items           []WasteItem      `json:"items"`
+}
+
+// Optimization mirrors the Optimization message in shared/proto/analytics.proto.
+type Optimization struct {
+	Type             string  `json:"type"`
+	Title            string  `json:"title"`
+	Description      string  `json:"description"`
+	PotentialSavings float64 `json:"potential_savings"`
+	Priority         string  `json:"priority"`
+}
+
+type wasteWindowSpec struct {
+	label    string
+	duration time.Duration
+}
+
+// parseWasteWindows reads a list such as "1h,24h,7d". Go durations have no
+// day unit, so a "d" suffix is handled here.
+func parseWasteWindows(value string) []wasteWindowSpec {
+	windows := []wasteWindowSpec{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var d time.Duration
+		var err error
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			var n float64
+			n, err = strconv.ParseFloat(days, 64)
+			d = time.Duration(n * float64(24*time.Hour))
+		} else {
+			d, err = time.ParseDuration(part)
+		}
+		if err != nil || d <= 0 {
+			log.Printf("Ignoring invalid waste window %q", part)
+			continue
+		}
+		windows = append(windows, wasteWindowSpec{label: part, duration: d})
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].duration < windows[j].duration })
+	return windows
+}
+
+func (s *CostTrackerServer) trackWastedSpend() {
+	interval := jobs.EnvDuration("WASTE_INTERVAL", 5*time.Minute)
+	s.jobs.Every("wasted_spend", interval, interval, s.calculateWastedSpend)
+}
+
+func (s *CostTrackerServer) calculateWastedSpend(ctx context.Context) error {
+	windowsEnv := os.Getenv("WASTE_WINDOWS")
+	if windowsEnv == "" {
+		windowsEnv = defaultWasteWindows
+	}
+	specs := parseWasteWindows(windowsEnv)
+	if len(specs) == 0 {
+		specs = parseWasteWindows(defaultWasteWindows)
+	}
+
+	// Each organization gets every window, empty where it had no traffic
+	perWindow := make([]map[int]*WasteWindow, len(specs))
+	orgs := map[int]bool{}
+	for i, spec := range specs {
+		windows, err := s.wasteForWindow(ctx, spec)
+		if err != nil {
+			return fmt.Errorf("window %s: %w", spec.label, err)
+		}
+		perWindow[i] = windows
+		for orgID := range windows {
+			orgs[orgID] = true
+		}
+	}
+
+	now := time.Now()
+	wasted, optimizations := map[int]interface{}{}, map[int]interface{}{}
+	var billable, nonBillable float64
+	for orgID := range orgs {
+		windows := []WasteWindow{}
+		for i, spec := range specs {
+			w, ok := perWindow[i][orgID]
+			if !ok {
+				w = newWasteWindow(spec)
+			}
+			windows = append(windows, *w)
+		}
+		wasted[orgID] = map[string]interface{}{
+			"windows":    windows,
+			"updated_at": now,
+		}
+
+		// Judge against the longest window, normalized to a daily rate
+		longest := windows[len(windows)-1]
+		optimizations[orgID] = wasteOptimizations(longest, specs[len(specs)-1].duration)
+		billable += longest.BillableCost
+		nonBillable += longest.NonBillableCost
+	}
+
+	empty := []WasteWindow{}
+	for _, spec := range specs {
+		empty = append(empty, *newWasteWindow(spec))
+	}
+	if err := s.orgs.Publish(ctx, "costs:wasted_spend", wasted, map[string]interface{}{"windows": empty, "updated_at": now}, 15*time.Minute); err != nil {
+		return err
+	}
+	if err := s.orgs.Publish(ctx, "costs:optimizations", optimizations, []Optimization{}, 15*time.Minute); err != nil {
+		return err
+	}
+
+	log.Printf("Wasted spend: $%.4f billable, $%.4f non-billable over %s", billable, nonBillable, specs[len(specs)-1].label)
+	return nil
+}
+
+func newWasteWindow(spec wasteWindowSpec) *WasteWindow {
+	return &WasteWindow{Window: spec.label, Providers: []*ProviderWaste{}, Items: []WasteItem{}}
+}
+
+// wasteForWindow totals failed calls over the window for each organization.
+func (s *CostTrackerServer) wasteForWindow(ctx context.Context, spec wasteWindowSpec) (map[int]*WasteWindow, error) {
+	seconds := spec.duration.Seconds()
+	windows := map[int]*WasteWindow{}
+	window := func(orgID int) *WasteWindow {
+		w, ok := windows[orgID]
+		if !ok {
+			w = newWasteWindow(spec)
+			windows[orgID] = w
+		}
+		return w
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT organization_id, COALESCE(SUM(cost), 0)
+        FROM api_requests
+        WHERE time > NOW() - make_interval(secs => $1)
+        GROUP BY organization_id
+    `, seconds)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var orgID int
+		var total float64
+		if err := rows.Scan(&orgID, &total); err == nil {
+			window(orgID).TotalCost = total
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT
+            r.organization_id,
+            r.provider,
+            r.endpoint,
+            COALESCE(r.error_class, 'unclassified') as error_class,
+            COALESCE(p.bills_failed_requests, FALSE) as billable,
+            COUNT(*) as requests,
+            COALESCE(SUM(r.cost), 0) as cost
+        FROM api_requests r
+        LEFT JOIN api_providers p ON p.name = r.provider
+        WHERE
+            r.time > NOW() - make_interval(secs => $1)
+            AND (r.status_code >= 400 OR r.error_class IS NOT NULL)
+        GROUP BY r.organization_id, r.provider, r.endpoint, error_class, billable
+        ORDER BY cost DESC
+    `
+
+	rows, err = s.db.QueryContext(ctx, query, seconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	providers := map[int]map[string]*ProviderWaste{}
+	for rows.Next() {
+		var orgID int
+		var item WasteItem
+		if err := rows.Scan(&orgID, &item.Provider, &item.Endpoint, &item.ErrorClass, &item.Billable, &item.Requests, &item.Cost); err != nil {
+			continue
+		}
+
+		w := window(orgID)
+		w.FailedRequests += item.Requests
+		w.FailedCost += item.Cost
+		if item.Billable {
+			w.BillableCost += item.Cost
+		} else {
+			w.NonBillableCost += item.Cost
+		}
+		if len(w.Items) < maxWasteItems {
+			w.Items = append(w.Items, item)
+		}
+
+		// Rows arrive most expensive first, so the first one seen is the top
+		if providers[orgID] == nil {
+			providers[orgID] = map[string]*ProviderWaste{}
+		}
+		p, ok := providers[orgID][item.Provider]
+		if !ok {
+			p = &ProviderWaste{
+				Provider:      item.Provider,
+				Billable:      item.Billable,
+				TopEndpoint:   item.Endpoint,
+				TopErrorClass: item.ErrorClass,
+			}
+			providers[orgID][item.Provider] = p
+			w.Providers = append(w.Providers, p)
+		}
+		p.Requests += item.Requests
+		p.Cost += item.Cost
+	}
+
+	for _, w := range windows {
+		sort.Slice(w.Providers, func(i, j int) bool { return w.Providers[i].Cost > w.Providers[j].Cost })
+		if w.TotalCost > 0 {
+			w.ShareOfSpend = 100.0 * w.FailedCost / w.TotalCost
+		}
+	}
+	return windows, rows.Err()
+}
+
+// wasteOptimizations suggests fixing the failures behind each provider's
+// billable waste once it exceeds WASTE_THRESHOLD dollars per day.
+func wasteOptimizations(w WasteWindow, duration time.Duration) []Optimization {
+	threshold := 1.0
+	if v, err := strconv.ParseFloat(os.Getenv("WASTE_THRESHOLD"), 64); err == nil {
+		threshold = v
+	}
+	days := duration.Hours() / 24
+
+	optimizations := []Optimization{}
+	for _, p := range w.Providers {
+		if !p.Billable {
+			continue
+		}
+		daily := p.Cost / days
+		if daily < threshold {
+			continue
+		}
+
+		priority := "medium"
+		if daily >= 10*threshold {
+			priority = "high"
+		}
+
+		optimizations = append(optimizations, Optimization{
+			Type:  "wasted_spend",
+			Title: fmt.Sprintf("Reduce billable failures on %s", p.Provider),
+			Description: fmt.Sprintf("%s charges for failed calls: %d failed requests cost $%.2f over %s ($%.2f/day). The largest source is %s errors on %s.",
+				p.Provider, p.Requests, p.Cost, w.Window, daily, p.TopErrorClass, p.TopEndpoint),
+			PotentialSavings: daily,
+			Priority:         priority,
+		})
+	}
+	return optimizations
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWasteWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"defaults", defaultWasteWindows, "1h=1h0m0s 24h=24h0m0s 7d=168h0m0s"},
+		{"sorted by duration", "7d, 30m ,1d", "30m=30m0s 1d=24h0m0s 7d=168h0m0s"},
+		{"fractional days", "0.5d", "0.5d=12h0m0s"},
+		{"compound duration", "1h30m", "1h30m=1h30m0s"},
+		{"empty entries", ",,24h,", "24h=24h0m0s"},
+		{"invalid entries are dropped", "bogus,24h,d,-1h,0d,3x", "24h=24h0m0s"},
+		{"nothing valid", "soon,later", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for i, w := range parseWasteWindows(tt.value) {
+				if i > 0 {
+					got += " "
+				}
+				got += fmt.Sprintf("%s=%s", w.label, w.duration)
+			}
+			if got != tt.expected {
+				t.Errorf("parseWasteWindows(%q) = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWasteOptimizations(t *testing.T) {
+	window := func(label string, providers ...*ProviderWaste) WasteWindow {
+		return WasteWindow{Window: label, Providers: providers}
+	}
+	billable := func(name string, cost float64) *ProviderWaste {
+		return &ProviderWaste{Provider: name, Billable: true, Requests: 10, Cost: cost, TopEndpoint: "/v1/x", TopErrorClass: "server_error"}
+	}
+
+	tests := []struct {
+		name      string
+		window    WasteWindow
+		duration  time.Duration
+		threshold string
+		expected  map[string]float64 // daily savings by provider
+		priority  map[string]string
+	}{
+		{
+			name:     "daily rate over a week",
+			window:   window("7d", billable("OpenAI", 70), billable("Stripe", 14)),
+			duration: 7 * 24 * time.Hour,
+			expected: map[string]float64{"OpenAI": 10, "Stripe": 2},
+			priority: map[string]string{"OpenAI": "high", "Stripe": "medium"},
+		},
+		{
+			name:     "short windows are scaled up to a day",
+			window:   window("1h", billable("OpenAI", 0.05)),
+			duration: time.Hour,
+			expected: map[string]float64{"OpenAI": 1.2},
+			priority: map[string]string{"OpenAI": "medium"},
+		},
+		{
+			name:     "below the threshold",
+			window:   window("24h", billable("OpenAI", 0.99)),
+			duration: 24 * time.Hour,
+		},
+		{
+			name:     "non-billable failures are not savings",
+			window:   window("24h", &ProviderWaste{Provider: "Stripe", Cost: 50}),
+			duration: 24 * time.Hour,
+		},
+		{
+			name:      "configured threshold",
+			window:    window("24h", billable("OpenAI", 30), billable("Stripe", 4)),
+			duration:  24 * time.Hour,
+			threshold: "5",
+			expected:  map[string]float64{"OpenAI": 30},
+			priority:  map[string]string{"OpenAI": "medium"},
+		},
+		{
+			name:      "invalid threshold falls back to a dollar a day",
+			window:    window("24h", billable("OpenAI", 2)),
+			duration:  24 * time.Hour,
+			threshold: "lots",
+			expected:  map[string]float64{"OpenAI": 2},
+			priority:  map[string]string{"OpenAI": "medium"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WASTE_THRESHOLD", tt.threshold)
+			got := wasteOptimizations(tt.window, tt.duration)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d optimizations, want %d: %+v", len(got), len(tt.expected), got)
+			}
+			for _, o := range got {
+				provider := strings.TrimPrefix(o.Title, "Reduce billable failures on ")
+				want, ok := tt.expected[provider]
+				if !ok {
+					t.Fatalf("unexpected optimization %q", o.Title)
+				}
+				if math.Abs(o.PotentialSavings-want) > 1e-9 {
+					t.Errorf("%s savings = %v, want %v", provider, o.PotentialSavings, want)
+				}
+				if o.Priority != tt.priority[provider] {
+					t.Errorf("%s priority = %q, want %q", provider, o.Priority, tt.priority[provider])
+				}
+			}
+		})
+	}
+}
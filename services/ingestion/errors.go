@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Error classes stored in api_requests.error_class.
+const (
+	errorAuth           = "auth"
+	errorRateLimit      = "rate_limit"
+	errorValidation     = "validation"
+	errorNotFound       = "not_found"
+	errorTimeout        = "timeout"
+	errorProviderOutage = "provider_outage"
+	errorClientBug      = "client_bug"
+)
+
+// providerErrorCodes maps error codes and types that providers put in their
+// error bodies to a class. Keys are lower-cased.
+var providerErrorCodes = map[string]map[string]string{
+	"OpenAI": {
+		"invalid_api_key":         errorAuth,
+		"authentication_error":    errorAuth,
+		"permission_error":        errorAuth,
+		"rate_limit_exceeded":     errorRateLimit,
+		"rate_limit_error":        errorRateLimit,
+		"insufficient_quota":      errorRateLimit,
+		"tokens":                  errorRateLimit,
+		"model_not_found":         errorNotFound,
+		"not_found_error":         errorNotFound,
+		"context_length_exceeded": errorValidation,
+		"invalid_request_error":   errorValidation,
+		"server_error":            errorProviderOutage,
+		"engine_overloaded":       errorProviderOutage,
+		"timeout":                 errorTimeout,
+	},
+	"Stripe": {
+		"authentication_error":  errorAuth,
+		"api_key_expired":       errorAuth,
+		"permission_error":      errorAuth,
+		"rate_limit":            errorRateLimit,
+		"rate_limit_error":      errorRateLimit,
+		"lock_timeout":          errorRateLimit,
+		"resource_missing":      errorNotFound,
+		"parameter_missing":     errorValidation,
+		"parameter_invalid":     errorValidation,
+		"parameter_unknown":     errorValidation,
+		"card_error":            errorValidation,
+		"idempotency_error":     errorClientBug,
+		"invalid_request_error": errorValidation,
+		"api_error":             errorProviderOutage,
+		"api_connection_error":  errorProviderOutage,
+	},
+	"Twilio": {
+		"20003": errorAuth,
+		"20005": errorAuth,
+		"20404": errorNotFound,
+		"20429": errorRateLimit,
+		"14107": errorRateLimit,
+		"30001": errorRateLimit,
+		"21211": errorValidation,
+		"21214": errorValidation,
+		"21408": errorAuth,
+		"21610": errorValidation,
+		"21614": errorValidation,
+		"20001": errorValidation,
+		"20500": errorProviderOutage,
+		"20503": errorProviderOutage,
+	},
+	"AWS S3": {
+		"accessdenied":          errorAuth,
+		"invalidaccesskeyid":    errorAuth,
+		"signaturedoesnotmatch": errorAuth,
+		"expiredtoken":          errorAuth,
+		"slowdown":              errorRateLimit,
+		"requestlimitexceeded":  errorRateLimit,
+		"throttling":            errorRateLimit,
+		"nosuchkey":             errorNotFound,
+		"nosuchbucket":          errorNotFound,
+		"nosuchupload":          errorNotFound,
+		"invalidargument":       errorValidation,
+		"malformedxml":          errorValidation,
+		"entitytoolarge":        errorValidation,
+		"invalidbucketname":     errorValidation,
+		"requesttimeout":        errorTimeout,
+		"internalerror":         errorProviderOutage,
+		"serviceunavailable":    errorProviderOutage,
+	},
+}
+
+// errorPatterns are checked in order against the lower-cased message, so
+// more specific phrases come first.
+var errorPatterns = []struct {
+	class   string
+	phrases []string
+}{
+	{errorTimeout, []string{"timed out", "timeout", "deadline exceeded"}},
+	{errorRateLimit, []string{"rate limit", "too many requests", "quota", "throttl", "slow down"}},
+	{errorAuth, []string{"unauthorized", "unauthenticated", "api key", "authentication", "forbidden", "permission", "access denied", "expired token"}},
+	{errorNotFound, []string{"not found", "no such", "does not exist"}},
+	{errorProviderOutage, []string{"service unavailable", "bad gateway", "internal server error", "overloaded", "outage", "connection refused", "connection reset"}},
+	{errorValidation, []string{"invalid", "required", "missing", "malformed", "must be", "too long", "too large"}},
+}
+
+// transportPatterns mark calls that failed without an error status, e.g.
+// status 0 because no response arrived.
+var transportPatterns = []struct {
+	class   string
+	phrases []string
+}{
+	{errorTimeout, []string{"timed out", "timeout", "deadline exceeded"}},
+	{errorProviderOutage, []string{"connection refused", "connection reset", "no such host", "broken pipe", "unexpected eof", "tls handshake"}},
+}
+
+// classifyError maps a failed call to one of the error classes, preferring
+// the provider's own error code, then the status code, then the message.
+// Calls without an error status only get a class when the message shows a
+// transport failure; otherwise they return "".
+func classifyError(provider string, statusCode int, message string) string {
+	if statusCode < 400 {
+		lower := strings.ToLower(message)
+		for _, p := range transportPatterns {
+			for _, phrase := range p.phrases {
+				if strings.Contains(lower, phrase) {
+					return p.class
+				}
+			}
+		}
+		return ""
+	}
+
+	if codes, ok := providerErrorCodes[provider]; ok {
+		for _, code := range providerErrorCodesIn(message) {
+			if class, ok := codes[code]; ok {
+				return class
+			}
+			// Stripe reports parameter_invalid_integer and friends.
+			if strings.HasPrefix(code, "parameter_invalid") {
+				return errorValidation
+			}
+		}
+	}
+
+	// SendGrid validation errors name the offending field.
+	var sendgrid struct {
+		Errors []struct {
+			Field string `json:"field"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal([]byte(message), &sendgrid) == nil && len(sendgrid.Errors) > 0 && sendgrid.Errors[0].Field != "" && statusCode < 500 {
+		return errorValidation
+	}
+
+	switch {
+	case statusCode == 401 || statusCode == 403:
+		return errorAuth
+	case statusCode == 429:
+		return errorRateLimit
+	case statusCode == 404 || statusCode == 410:
+		return errorNotFound
+	case statusCode == 408 || statusCode == 504:
+		return errorTimeout
+	case statusCode >= 500:
+		return errorProviderOutage
+	}
+
+	lower := strings.ToLower(message)
+	for _, p := range errorPatterns {
+		for _, phrase := range p.phrases {
+			if strings.Contains(lower, phrase) {
+				return p.class
+			}
+		}
+	}
+
+	switch statusCode {
+	case 400, 409, 413, 415, 422:
+		return errorValidation
+	default:
+		return errorClientBug
+	}
+}
+
+// providerErrorCodesIn extracts lower-cased error codes and types from a
+// JSON body in the OpenAI/Stripe ({"error": {"code", "type"}}) or Twilio
+// ({"code": 20003}) shape, or from an S3 XML <Error><Code>.
+func providerErrorCodesIn(message string) []string {
+	message = strings.TrimSpace(message)
+	codes := []string{}
+
+	if strings.HasPrefix(message, "{") {
+		var body struct {
+			Error *struct {
+				Code interface{} `json:"code"`
+				Type string      `json:"type"`
+			} `json:"error"`
+			Code interface{} `json:"code"`
+		}
+		if json.Unmarshal([]byte(message), &body) != nil {
+			return codes
+		}
+		if body.Error != nil {
+			if body.Error.Code != nil {
+				codes = append(codes, strings.ToLower(fmt.Sprint(body.Error.Code)))
+			}
+			if body.Error.Type != "" {
+				codes = append(codes, strings.ToLower(body.Error.Type))
+			}
+		}
+		if body.Code != nil {
+			if f, ok := body.Code.(float64); ok {
+				codes = append(codes, fmt.Sprintf("%.0f", f))
+			} else {
+				codes = append(codes, strings.ToLower(fmt.Sprint(body.Code)))
+			}
+		}
+		return codes
+	}
+
+	if strings.HasPrefix(message, "<") {
+		var body struct {
+			Code string `xml:"Code"`
+		}
+		if xml.Unmarshal([]byte(message), &body) == nil && body.Code != "" {
+			codes = append(codes, strings.ToLower(body.Code))
+		}
+	}
+	return codes
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProviderErrorCodesIn(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{`{"error": {"message": "Incorrect API key", "type": "invalid_request_error", "code": "invalid_api_key"}}`, []string{"invalid_api_key", "invalid_request_error"}},
+		{`{"error": {"type": "card_error", "code": "Parameter_Invalid_Integer"}}`, []string{"parameter_invalid_integer", "card_error"}},
+		{`{"error": {"code": null, "type": "server_error"}}`, []string{"server_error"}},
+		{`{"code": 20003, "message": "Authenticate", "status": 401}`, []string{"20003"}},
+		{`{"code": "E42"}`, []string{"e42"}},
+		{"  <?xml version=\"1.0\"?>\n<Error><Code>NoSuchKey</Code><Message>The key does not exist</Message></Error>", []string{"nosuchkey"}},
+		{`<Error><Message>no code</Message></Error>`, []string{}},
+		{`{"error": `, []string{}},
+		{"upstream connect error", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := providerErrorCodesIn(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("providerErrorCodesIn(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		message  string
+		want     string
+	}{
+		// Provider codes win over the status code.
+		{"openai quota on 429", "OpenAI", 429, `{"error": {"code": "insufficient_quota", "type": "insufficient_quota"}}`, errorRateLimit},
+		{"openai context length", "OpenAI", 400, `{"error": {"code": "context_length_exceeded", "type": "invalid_request_error"}}`, errorValidation},
+		{"openai type without known code", "OpenAI", 503, `{"error": {"code": "unknown", "type": "server_error"}}`, errorProviderOutage},
+		{"stripe missing resource", "Stripe", 400, `{"error": {"code": "resource_missing", "type": "invalid_request_error"}}`, errorNotFound},
+		{"stripe idempotency", "Stripe", 400, `{"error": {"type": "idempotency_error"}}`, errorClientBug},
+		{"stripe parameter_invalid family", "Stripe", 400, `{"error": {"code": "parameter_invalid_string_empty"}}`, errorValidation},
+		{"twilio numeric code", "Twilio", 400, `{"code": 21211, "message": "Invalid 'To' Phone Number"}`, errorValidation},
+		{"twilio auth code on 401", "Twilio", 401, `{"code": 20003}`, errorAuth},
+		{"s3 xml throttling", "AWS S3", 503, "<Error><Code>SlowDown</Code></Error>", errorRateLimit},
+		{"s3 xml missing key", "AWS S3", 404, "<Error><Code>NoSuchKey</Code></Error>", errorNotFound},
+		{"codes of another provider are ignored", "SendGrid", 400, `{"code": 20003}`, errorValidation},
+		{"sendgrid field error", "SendGrid", 400, `{"errors": [{"field": "from", "message": "bad"}]}`, errorValidation},
+
+		// The status code is checked before the message.
+		{"401 with a timeout message", "OpenAI", 401, "request timed out while authenticating", errorAuth},
+		{"404 mentioning a rate limit", "Stripe", 404, "rate limit docs not found", errorNotFound},
+		{"503 with an invalid message", "Stripe", 503, "invalid upstream response", errorProviderOutage},
+		{"504 gateway timeout", "OpenAI", 504, "Bad Gateway", errorTimeout},
+		{"410 gone", "Stripe", 410, "", errorNotFound},
+
+		// Other 4xx fall back to the message, then the status.
+		{"400 quota message", "Mailgun", 400, "Daily quota reached", errorRateLimit},
+		{"400 timeout before rate limit", "Mailgun", 400, "timeout waiting for rate limit slot", errorTimeout},
+		{"422 without a message", "Mailgun", 422, "", errorValidation},
+		{"418 without a message", "Mailgun", 418, "", errorClientBug},
+
+		// Calls without an error status are only classified on transport failures.
+		{"status 0 timeout", "OpenAI", 0, "context deadline exceeded", errorTimeout},
+		{"status 0 connection refused", "Stripe", 0, "dial tcp: connection refused", errorProviderOutage},
+		{"200 with a reset body", "Stripe", 200, "read: connection reset by peer", errorProviderOutage},
+		{"200 with an error code body", "OpenAI", 200, `{"error": {"code": "rate_limit_exceeded"}}`, ""},
+		{"304 with a validation message", "Stripe", 304, "invalid etag", ""},
+		{"status 0 without a message", "Stripe", 0, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.provider, tt.status, tt.message); got != tt.want {
+				t.Errorf("classifyError(%q, %d, %q) = %q, want %q", tt.provider, tt.status, tt.message, got, tt.want)
+			}
+		})
+	}
+}
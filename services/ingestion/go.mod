@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/lib/pq v1.10.9
+	github.com/yourusername/api-observatory/shared v0.0.0
 )
 
 require (
@@ -13,3 +14,5 @@ require (
 	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
 )
+
+replace github.com/yourusername/api-observatory/shared => ../../shared
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,6 +20,10 @@ type Server struct {
 	redis             *redis.Client
 	requestsProcessed atomic.Int64
 	startTime         time.Time
+
+	pricesMu  sync.Mutex
+	prices    map[string]*providerPrices   // by provider
+	discounts map[string]cachedCommitments // by organization|provider
 }
 
 type APIRequest struct {
@@ -92,6 +97,8 @@ func main() {
 		db:        db,
 		redis:     rdb,
 		startTime: time.Now(),
+		prices:    map[string]*providerPrices{},
+		discounts: map[string]cachedCommitments{},
 	}
 
 	// Create a new ServeMux
@@ -181,7 +188,12 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Calculate cost
-	cost := s.calculateCost(req.Provider, req.RequestSizeBytes, req.ResponseSizeBytes)
+	requestTime := time.Now()
+	if req.Timestamp > 0 {
+		requestTime = time.UnixMilli(req.Timestamp)
+	}
+	cost, priceVersionID := s.calculateCost(req.OrganizationID, req.Provider, requestTime, req.RequestSizeBytes, req.ResponseSizeBytes)
+	errorClass := classifyError(req.Provider, req.StatusCode, req.ErrorMessage)
 
 	// Store in database if available
 	if s.db != nil {
@@ -194,14 +206,14 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 			INSERT INTO api_requests (
 				time, organization_id, request_id, provider, endpoint, method,
 				status_code, latency_ms, request_size_bytes, response_size_bytes,
-				cost, error_message, metadata
+				cost, error_message, error_class, metadata, price_version_id
 			) VALUES (
-				to_timestamp($1), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+				to_timestamp($1), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''), $14, NULLIF($15, 0)
 			)
 		`
 
 		_, err := s.db.ExecContext(ctx, query,
-			float64(req.Timestamp)/1000.0,
+			float64(requestTime.UnixMilli())/1000.0,
 			req.OrganizationID,
 			req.RequestID,
 			req.Provider,
@@ -213,13 +225,16 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 			req.ResponseSizeBytes,
 			cost,
 			req.ErrorMessage,
+			errorClass,
 			metadataJSON,
+			priceVersionID,
 		)
 
 		if err != nil {
 			log.Printf("Failed to insert request: %v", err)
 		} else {
 			log.Printf("Stored request in database: %s", req.RequestID)
+			s.recordRollup(ctx, &req, requestTime, cost, errorClass)
 		}
 	}
 
@@ -229,10 +244,17 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 	// Publish to Redis if available
 	if s.redis != nil {
 		event := map[string]interface{}{
-			"type":      "new_request",
-			"provider":  req.Provider,
-			"cost":      cost,
-			"timestamp": req.Timestamp,
+			"type":            "new_request",
+			"request_id":      req.RequestID,
+			"organization_id": req.OrganizationID,
+			"provider":        req.Provider,
+			"endpoint":        req.Endpoint,
+			"method":          req.Method,
+			"status_code":     req.StatusCode,
+			"latency_ms":      req.LatencyMS,
+			"cost":            cost,
+			"error_class":     errorClass,
+			"timestamp":       requestTime.UnixMilli(),
 		}
 		eventJSON, _ := json.Marshal(event)
 		s.redis.Publish(context.Background(), "api_events", eventJSON)
@@ -282,23 +304,3 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(health)
 }
-
-func (s *Server) calculateCost(provider string, reqSize, respSize int) float64 {
-	baseCost := 0.001
-
-	if s.db != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-
-		query := "SELECT base_cost_per_request FROM api_providers WHERE name = $1"
-		err := s.db.QueryRowContext(ctx, query, provider).Scan(&baseCost)
-		if err != nil {
-			baseCost = 0.001
-		}
-	}
-
-	totalSize := float64(reqSize+respSize) / 1024.0
-	sizeCost := totalSize * 0.00001
-
-	return baseCost + sizeCost
-}
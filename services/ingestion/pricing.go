@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/api-observatory/shared/billing"
+)
+
+const (
+	defaultBaseCost = 0.001
+	priceCacheTTL   = time.Minute
+)
+
+type priceTier struct {
+	from     int64
+	unitCost float64
+}
+
+// priceVersion is a provider's pricing from effectiveFrom until the next
+// version. Tiers are sorted by from and reset every billing month.
+type priceVersion struct {
+	id            int
+	effectiveFrom time.Time
+	base          float64
+	free          int64
+	tiers         []priceTier
+}
+
+type providerPrices struct {
+	versions []priceVersion // sorted by effectiveFrom
+	loadedAt time.Time
+}
+
+// commitment is a committed-use discount in force on days in
+// [startsOn, endsOn); endsOn is zero when open-ended.
+type commitment struct {
+	startsOn time.Time
+	endsOn   time.Time
+	percent  float64
+}
+
+type cachedCommitments struct {
+	commitments []commitment
+	loadedAt    time.Time
+}
+
+// discountAt returns the largest discount in force on the UTC day of at,
+// as repriceQuery does.
+func (c cachedCommitments) discountAt(at time.Time) float64 {
+	at = at.UTC()
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	discount := 0.0
+	for _, cm := range c.commitments {
+		if cm.startsOn.After(day) || (!cm.endsOn.IsZero() && !cm.endsOn.After(day)) {
+			continue
+		}
+		if cm.percent > discount {
+			discount = cm.percent
+		}
+	}
+	return discount
+}
+
+// priceSchedule is the pricing that applies to one organization's request.
+type priceSchedule struct {
+	version  *priceVersion // nil when the provider has no price versions
+	discount float64       // committed-use discount, percent
+}
+
+// volumePriced reports whether the unit price depends on period usage.
+func (p priceSchedule) volumePriced() bool {
+	return p.version != nil && (p.version.free > 0 || len(p.version.tiers) > 0)
+}
+
+// unitCost prices the n-th request of the billing period. It must agree
+// with repriceQuery in the cost-tracker: request n is free while
+// n <= free, and a tier applies once n > from.
+func (p priceSchedule) unitCost(n int64) float64 {
+	if p.version == nil {
+		return defaultBaseCost * (1 - p.discount/100)
+	}
+	if n <= p.version.free {
+		return 0
+	}
+	unit := p.version.base
+	for _, t := range p.version.tiers {
+		if n > t.from {
+			unit = t.unitCost
+		}
+	}
+	return unit * (1 - p.discount/100)
+}
+
+func billingPeriod(at time.Time) time.Time {
+	at = at.UTC()
+	return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Server) priceSchedule(ctx context.Context, orgID, provider string, at time.Time) priceSchedule {
+	schedule := priceSchedule{}
+	if prices := s.providerPrices(ctx, provider); prices != nil {
+		for i := range prices.versions {
+			if !prices.versions[i].effectiveFrom.After(at) {
+				schedule.version = &prices.versions[i]
+			}
+		}
+	}
+	schedule.discount = s.commitmentDiscount(ctx, orgID, provider, at)
+	return schedule
+}
+
+func (s *Server) providerPrices(ctx context.Context, provider string) *providerPrices {
+	s.pricesMu.Lock()
+	cached, ok := s.prices[provider]
+	s.pricesMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < priceCacheTTL {
+		return cached
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT v.id, v.effective_from, v.base_cost_per_request, v.free_requests_per_period, t.from_requests, t.unit_cost
+        FROM price_versions v
+        LEFT JOIN pricing_tiers t ON t.price_version_id = v.id
+        WHERE v.provider = $1
+        ORDER BY v.effective_from, t.from_requests
+    `, provider)
+	if err != nil {
+		log.Printf("Failed to load %s prices: %v", provider, err)
+		return cached
+	}
+	defer rows.Close()
+
+	prices := &providerPrices{loadedAt: time.Now()}
+	for rows.Next() {
+		var v priceVersion
+		var from sql.NullInt64
+		var unitCost sql.NullFloat64
+		if err := rows.Scan(&v.id, &v.effectiveFrom, &v.base, &v.free, &from, &unitCost); err != nil {
+			continue
+		}
+		if n := len(prices.versions); n == 0 || prices.versions[n-1].id != v.id {
+			prices.versions = append(prices.versions, v)
+		}
+		if from.Valid {
+			last := &prices.versions[len(prices.versions)-1]
+			last.tiers = append(last.tiers, priceTier{from: from.Int64, unitCost: unitCost.Float64})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to load %s prices: %v", provider, err)
+		return cached
+	}
+
+	s.pricesMu.Lock()
+	s.prices[provider] = prices
+	s.pricesMu.Unlock()
+	return prices
+}
+
+// commitmentDiscount returns the organization's committed-use discount on
+// the provider in force at the request time, which for late or backfilled
+// requests is not today.
+func (s *Server) commitmentDiscount(ctx context.Context, orgID, provider string, at time.Time) float64 {
+	key := orgID + "|" + provider
+	s.pricesMu.Lock()
+	cached, ok := s.discounts[key]
+	s.pricesMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < priceCacheTTL {
+		return cached.discountAt(at)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT starts_on, ends_on, discount_percent FROM pricing_commitments
+        WHERE organization_id = $1 AND provider = $2 AND discount_percent > 0
+    `, orgID, provider)
+	if err != nil {
+		log.Printf("Failed to load commitments for org %s on %s: %v", orgID, provider, err)
+		return cached.discountAt(at)
+	}
+	defer rows.Close()
+
+	loaded := cachedCommitments{loadedAt: time.Now()}
+	for rows.Next() {
+		var cm commitment
+		var endsOn sql.NullTime
+		if err := rows.Scan(&cm.startsOn, &endsOn, &cm.percent); err != nil {
+			continue
+		}
+		cm.startsOn = cm.startsOn.UTC()
+		if endsOn.Valid {
+			cm.endsOn = endsOn.Time.UTC()
+		}
+		loaded.commitments = append(loaded.commitments, cm)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to load commitments for org %s on %s: %v", orgID, provider, err)
+		return cached.discountAt(at)
+	}
+
+	s.pricesMu.Lock()
+	s.discounts[key] = loaded
+	s.pricesMu.Unlock()
+	return loaded.discountAt(at)
+}
+
+// periodUsage counts this request into the organization's usage of the
+// provider for the billing month and returns its position. The Redis counter
+// is seeded from the database the first time a period is seen; the
+// cost-tracker's true-up job fixes any drift.
+func (s *Server) periodUsage(ctx context.Context, orgID, provider string, at time.Time) int64 {
+	period := billingPeriod(at)
+	end := period.AddDate(0, 1, 0)
+
+	if s.redis != nil {
+		key := fmt.Sprintf("usage:%s:%s:%s", orgID, provider, period.Format("2006-01"))
+		if exists, err := s.redis.Exists(ctx, key).Result(); err == nil && exists == 0 {
+			count := s.countPeriodRequests(ctx, orgID, provider, period, end)
+			s.redis.SetNX(ctx, key, count, 0)
+			s.redis.ExpireAt(ctx, key, end.AddDate(0, 0, 7))
+		}
+		if n, err := s.redis.Incr(ctx, key).Result(); err == nil {
+			return n
+		}
+	}
+	return s.countPeriodRequests(ctx, orgID, provider, period, end) + 1
+}
+
+func (s *Server) countPeriodRequests(ctx context.Context, orgID, provider string, start, end time.Time) int64 {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM api_requests
+        WHERE organization_id = $1 AND provider = $2 AND time >= $3 AND time < $4
+    `, orgID, provider, start, end).Scan(&count)
+	if err != nil {
+		log.Printf("Failed to count %s usage for org %s: %v", provider, orgID, err)
+	}
+	return count
+}
+
+// calculateCost prices a request from the provider's price version in
+// effect at the request time and the organization's cumulative usage of the
+// provider in that billing month, plus a size charge. It returns the cost
+// and the price version applied (0 when none).
+func (s *Server) calculateCost(orgID, provider string, at time.Time, reqSize, respSize int) (float64, int) {
+	unit := defaultBaseCost
+	versionID := 0
+
+	if s.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		p := s.priceSchedule(ctx, orgID, provider, at)
+		n := int64(1)
+		if p.volumePriced() {
+			n = s.periodUsage(ctx, orgID, provider, at)
+		}
+		unit = p.unitCost(n)
+		if p.version != nil {
+			versionID = p.version.id
+		}
+	}
+
+	totalSize := float64(reqSize+respSize) / 1024.0
+	return unit + totalSize*billing.SizeCostPerKB, versionID
+}
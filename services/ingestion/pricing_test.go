@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// repriceUnitCost mirrors the unit price expression of repriceQuery in the
+// cost-tracker, so the two pricing paths can be compared without a database:
+//
+//	CASE WHEN n <= free_requests_per_period THEN 0
+//	ELSE COALESCE(<unit_cost of the last tier with from_requests < n>, base_cost_per_request)
+//	     * (1 - discount / 100)
+//	END
+func repriceUnitCost(v priceVersion, discount float64, n int64) float64 {
+	if n <= v.free {
+		return 0
+	}
+	unit := v.base
+	best := int64(-1)
+	for _, t := range v.tiers {
+		if t.from < n && t.from > best {
+			best = t.from
+			unit = t.unitCost
+		}
+	}
+	return unit * (1 - discount/100)
+}
+
+func TestUnitCostMatchesReprice(t *testing.T) {
+	tiered := priceVersion{
+		id:   1,
+		base: 0.01,
+		free: 100,
+		tiers: []priceTier{
+			{from: 1000, unitCost: 0.008},
+			{from: 10000, unitCost: 0.005},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		version  priceVersion
+		discount float64
+		n        int64
+		want     float64
+	}{
+		{"first request is free", tiered, 0, 1, 0},
+		{"last free request", tiered, 0, 100, 0},
+		{"first paid request", tiered, 0, 101, 0.01},
+		{"tier start still on base price", tiered, 0, 1000, 0.01},
+		{"first request past tier start", tiered, 0, 1001, 0.008},
+		{"second tier start", tiered, 0, 10000, 0.008},
+		{"past second tier start", tiered, 0, 10001, 0.005},
+		{"discount on tier", tiered, 20, 1001, 0.0064},
+		{"discount keeps free requests free", tiered, 20, 100, 0},
+		{"tier from zero replaces base", priceVersion{base: 0.01, tiers: []priceTier{{from: 0, unitCost: 0.009}}}, 0, 1, 0.009},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version := tt.version
+			got := priceSchedule{version: &version, discount: tt.discount}.unitCost(tt.n)
+			reprice := repriceUnitCost(tt.version, tt.discount, tt.n)
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("unitCost(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+			if math.Abs(got-reprice) > 1e-12 {
+				t.Errorf("unitCost(%d) = %v, but repriceQuery prices it at %v", tt.n, got, reprice)
+			}
+		})
+	}
+}
+
+func TestUnitCostWithoutPriceVersion(t *testing.T) {
+	tests := []struct {
+		discount float64
+		want     float64
+	}{
+		{0, defaultBaseCost},
+		{25, defaultBaseCost * 0.75},
+	}
+	for _, tt := range tests {
+		if got := (priceSchedule{discount: tt.discount}).unitCost(1); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("unitCost with %.0f%% discount = %v, want %v", tt.discount, got, tt.want)
+		}
+	}
+}
+
+func TestDiscountAt(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	c := cachedCommitments{commitments: []commitment{
+		{startsOn: day("2025-01-01"), endsOn: day("2025-02-01"), percent: 10},
+		{startsOn: day("2025-01-15"), percent: 15},
+	}}
+
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{day("2024-12-31").Add(23 * time.Hour), 0},
+		{day("2025-01-01"), 10},
+		{day("2025-01-14").Add(23*time.Hour + 59*time.Minute), 10},
+		{day("2025-01-15"), 15},
+		{day("2025-02-01"), 15},
+		{time.Date(2025, 1, 1, 1, 0, 0, 0, time.FixedZone("EST", -5*3600)), 10},
+		{time.Date(2024, 12, 31, 20, 0, 0, 0, time.FixedZone("EST", -5*3600)), 10},
+	}
+	for _, tt := range tests {
+		if got := c.discountAt(tt.at); got != tt.want {
+			t.Errorf("discountAt(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
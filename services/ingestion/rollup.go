@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// rollupLateness is how far behind a request may arrive and still be
+	// counted in its minute's bucket. The cost-tracker folds a minute into
+	// its rolling 24h totals once this has passed; anything later is only
+	// picked up by its periodic full recompute.
+	rollupLateness  = 2 * time.Minute
+	rollupBucketTTL = 25 * time.Hour
+)
+
+// recordRollup adds a stored request to the per-minute rollup hash
+// rollup:1m:<unix minute>, under "<org>|<provider>|<metric>" fields.
+func (s *Server) recordRollup(ctx context.Context, req *APIRequest, at time.Time, cost float64, errorClass string) {
+	if s.redis == nil || time.Since(at) > rollupLateness {
+		return
+	}
+
+	key := fmt.Sprintf("rollup:1m:%d", at.Unix()/60)
+	prefix := req.OrganizationID + "|" + req.Provider + "|"
+
+	pipe := s.redis.Pipeline()
+	pipe.HIncrBy(ctx, key, prefix+"requests", 1)
+	pipe.HIncrByFloat(ctx, key, prefix+"cost", cost)
+	pipe.HIncrBy(ctx, key, prefix+"latency_ms", int64(req.LatencyMS))
+	if req.StatusCode >= 400 {
+		pipe.HIncrBy(ctx, key, prefix+"errors", 1)
+	}
+	if req.StatusCode >= 400 || errorClass != "" {
+		class := errorClass
+		if class == "" {
+			class = "unclassified"
+		}
+		pipe.HIncrBy(ctx, key, prefix+"class:"+class, 1)
+	}
+	pipe.Expire(ctx, key, rollupBucketTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to update cost rollup: %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+// Package billing holds the pricing terms every service must agree on when
+// it computes or estimates a request's cost.
+package billing
+
+// SizeCostPerKB is charged per KB of request and response body on top of a
+// request's unit price.
+const SizeCostPerKB = 0.00001
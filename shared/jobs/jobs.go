@@ -0,0 +1,185 @@
+// Package jobs schedules periodic work across service replicas.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// acquireLease takes the job lease or renews it if this instance already
+// holds it. Holding the lease across runs keeps a job on one replica until
+// that replica stops renewing it.
+var acquireLease = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+    return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+    redis.call("PEXPIRE", KEYS[1], ARGV[2])
+    return 1
+end
+return 0
+`)
+
+// Scheduler runs periodic jobs so that, across any number of replicas,
+// each job runs on only one of them per interval. Leases and the last run
+// status of every job live in Redis.
+type Scheduler struct {
+	redis    *redis.Client
+	service  string
+	instance string
+	jitter   time.Duration
+}
+
+func NewScheduler(rdb *redis.Client, service string) *Scheduler {
+	if name := os.Getenv("SERVICE_NAME"); name != "" {
+		service = name
+	}
+	host, _ := os.Hostname()
+
+	return &Scheduler{
+		redis:    rdb,
+		service:  service,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		jitter:   EnvDuration("JOB_JITTER", 5*time.Second),
+	}
+}
+
+// Instance identifies this replica in leases and job status.
+func (j *Scheduler) Instance() string {
+	return j.instance
+}
+
+// Every runs fn each interval, after a random delay of up to the configured
+// jitter, whenever this instance holds the job's lease. It never returns.
+func (j *Scheduler) Every(name string, interval, timeout time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if j.jitter > 0 {
+			time.Sleep(time.Duration(rand.Int63n(int64(j.jitter))))
+		}
+		j.runOnce(name, interval, timeout, fn)
+		<-ticker.C
+	}
+}
+
+func (j *Scheduler) runOnce(name string, interval, timeout time.Duration, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The lease outlives the interval slightly so the holder renews it on its
+	// next tick before any other replica can take it.
+	lease := interval + j.jitter + 5*time.Second
+	held, err := acquireLease.Run(ctx, j.redis, []string{j.leaseKey(name)}, j.instance, lease.Milliseconds()).Int()
+	if err != nil {
+		log.Printf("Job %s: failed to acquire lease: %v", name, err)
+		return
+	}
+	if held == 0 {
+		return
+	}
+
+	// Keep renewing the lease while fn runs so a job that outlasts it stays
+	// exclusive. If this instance loses the lease, fn is cancelled rather
+	// than left running alongside the new holder.
+	ctx, stopRenew := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		j.renewLease(ctx, name, interval/3, lease)
+		stopRenew()
+	}()
+	defer func() {
+		stopRenew()
+		<-renewed
+	}()
+
+	start := time.Now()
+	err = func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return fn(ctx)
+	}()
+
+	status := map[string]interface{}{
+		"status":      "ok",
+		"error":       "",
+		"instance":    j.instance,
+		"last_run":    start.Format(time.RFC3339),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"interval":    interval.String(),
+	}
+	if err != nil {
+		status["status"] = "error"
+		status["error"] = err.Error()
+		log.Printf("Job %s failed after %s: %v", name, time.Since(start).Round(time.Millisecond), err)
+	}
+
+	// Record with a fresh context so a timed-out job still reports it.
+	recordCtx, recordCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer recordCancel()
+	if err := j.redis.HSet(recordCtx, j.statusKey(name), status).Err(); err != nil {
+		log.Printf("Job %s: failed to record status: %v", name, err)
+	}
+}
+
+// renewLease extends the job lease every period until ctx is done. It returns
+// early if the lease cannot be renewed, either because Redis is unreachable
+// or because another instance now holds it.
+func (j *Scheduler) renewLease(ctx context.Context, name string, period, lease time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		held, err := acquireLease.Run(ctx, j.redis, []string{j.leaseKey(name)}, j.instance, lease.Milliseconds()).Int()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Job %s: failed to renew lease, cancelling: %v", name, err)
+			return
+		}
+		if held == 0 {
+			log.Printf("Job %s: lease taken by another instance, cancelling", name)
+			return
+		}
+	}
+}
+
+func (j *Scheduler) leaseKey(name string) string {
+	return fmt.Sprintf("jobs:lease:%s:%s", j.service, name)
+}
+
+func (j *Scheduler) statusKey(name string) string {
+	return fmt.Sprintf("jobs:status:%s:%s", j.service, name)
+}
+
+// EnvDuration reads a duration such as "30m" from the environment, falling
+// back to def when the variable is unset or invalid.
+func EnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s", key, value, def)
+		return def
+	}
+	return d
+}
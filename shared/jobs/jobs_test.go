@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestScheduler(t *testing.T, instance string) (*Scheduler, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return &Scheduler{redis: rdb, service: "test", instance: instance}, mr
+}
+
+func TestAcquireLease(t *testing.T) {
+	ctx := context.Background()
+	j, mr := newTestScheduler(t, "a")
+	key := []string{j.leaseKey("job")}
+	acquire := func(instance string, lease time.Duration) int {
+		t.Helper()
+		held, err := acquireLease.Run(ctx, j.redis, key, instance, lease.Milliseconds()).Int()
+		if err != nil {
+			t.Fatalf("acquireLease: %v", err)
+		}
+		return held
+	}
+
+	if got := acquire("a", time.Minute); got != 1 {
+		t.Fatalf("free lease: got %d, want 1", got)
+	}
+	if got := acquire("b", time.Minute); got != 0 {
+		t.Fatalf("lease held by another instance: got %d, want 0", got)
+	}
+
+	mr.FastForward(30 * time.Second)
+	if got := acquire("a", time.Minute); got != 1 {
+		t.Fatalf("renewal by holder: got %d, want 1", got)
+	}
+	if ttl := mr.TTL(key[0]); ttl != time.Minute {
+		t.Fatalf("renewal left TTL %s, want %s", ttl, time.Minute)
+	}
+
+	mr.FastForward(time.Minute + time.Second)
+	if got := acquire("b", time.Minute); got != 1 {
+		t.Fatalf("expired lease: got %d, want 1", got)
+	}
+	if holder, _ := mr.Get(key[0]); holder != "b" {
+		t.Fatalf("lease holder = %q, want %q", holder, "b")
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	t.Run("records success", func(t *testing.T) {
+		j, mr := newTestScheduler(t, "a")
+		ran := false
+		j.runOnce("job", time.Minute, time.Second, func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+		if !ran {
+			t.Fatal("job did not run with a free lease")
+		}
+		if status := mr.HGet(j.statusKey("job"), "status"); status != "ok" {
+			t.Fatalf("status = %q, want ok", status)
+		}
+		if holder, _ := mr.Get(j.leaseKey("job")); holder != "a" {
+			t.Fatalf("lease holder = %q, want a", holder)
+		}
+	})
+
+	t.Run("records failure and panic", func(t *testing.T) {
+		j, mr := newTestScheduler(t, "a")
+		j.runOnce("job", time.Minute, time.Second, func(ctx context.Context) error {
+			return errors.New("boom")
+		})
+		if got := mr.HGet(j.statusKey("job"), "error"); got != "boom" {
+			t.Fatalf("error = %q, want boom", got)
+		}
+		j.runOnce("job", time.Minute, time.Second, func(ctx context.Context) error {
+			panic("bad")
+		})
+		if got := mr.HGet(j.statusKey("job"), "error"); got != "panic: bad" {
+			t.Fatalf("error = %q, want panic: bad", got)
+		}
+	})
+
+	t.Run("skips when another instance holds the lease", func(t *testing.T) {
+		j, mr := newTestScheduler(t, "a")
+		mr.Set(j.leaseKey("job"), "b")
+		j.runOnce("job", time.Minute, time.Second, func(ctx context.Context) error {
+			t.Fatal("job ran without the lease")
+			return nil
+		})
+		if mr.Exists(j.statusKey("job")) {
+			t.Fatal("skipped run recorded a status")
+		}
+	})
+
+	t.Run("renews the lease while the job runs", func(t *testing.T) {
+		j, mr := newTestScheduler(t, "a")
+		interval := 300 * time.Millisecond
+		j.runOnce("job", interval, 5*time.Second, func(ctx context.Context) error {
+			// Expire the lease as if the job had outlived it; only the
+			// renewal can bring it back before the job finishes.
+			mr.Del(j.leaseKey("job"))
+			select {
+			case <-time.After(2 * interval):
+			case <-ctx.Done():
+				t.Fatal("job cancelled while it still held the lease")
+			}
+			if holder, _ := mr.Get(j.leaseKey("job")); holder != "a" {
+				t.Fatalf("lease holder during run = %q, want a", holder)
+			}
+			return nil
+		})
+	})
+
+	t.Run("cancels the job when the lease is lost", func(t *testing.T) {
+		j, mr := newTestScheduler(t, "a")
+		interval := 300 * time.Millisecond
+		j.runOnce("job", interval, 5*time.Second, func(ctx context.Context) error {
+			mr.Set(j.leaseKey("job"), "b")
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+				t.Fatal("job kept running after losing the lease")
+				return nil
+			}
+		})
+		if status := mr.HGet(j.statusKey("job"), "status"); status != "error" {
+			t.Fatalf("status = %q, want error", status)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Package tenant caches per-organization results in Redis under the keys
+// the API gateway serves to each organization.
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Key scopes a Redis key to one organization. The gateway only serves a
+// caller the keys of its own organization.
+func Key(key string, orgID int) string {
+	return fmt.Sprintf("%s:org:%d", key, orgID)
+}
+
+// Publisher writes one value per organization.
+type Publisher struct {
+	db    *sql.DB
+	redis *redis.Client
+}
+
+func NewPublisher(db *sql.DB, rdb *redis.Client) *Publisher {
+	return &Publisher{db: db, redis: rdb}
+}
+
+// OrganizationIDs lists every organization.
+func (p *Publisher) OrganizationIDs(ctx context.Context) ([]int, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id FROM organizations ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// Publish caches each organization's value under its own key.
+// Organizations without a value get empty, so a tenant never keeps reading
+// a stale result.
+func (p *Publisher) Publish(ctx context.Context, key string, byOrg map[int]interface{}, empty interface{}, ttl time.Duration) error {
+	orgIDs, err := p.OrganizationIDs(ctx)
+	if err != nil {
+		return err
+	}
+	seen := map[int]bool{}
+	for _, orgID := range orgIDs {
+		seen[orgID] = true
+	}
+	for orgID := range byOrg {
+		if !seen[orgID] {
+			orgIDs = append(orgIDs, orgID)
+		}
+	}
+
+	pipe := p.redis.Pipeline()
+	for _, orgID := range orgIDs {
+		value, ok := byOrg[orgID]
+		if !ok {
+			value = empty
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, Key(key, orgID), data, ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,64 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectQuery(`SELECT id FROM organizations`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	// Org 2 had findings on the previous run that are now gone
+	mr.Set(Key("findings", 2), `[{"stale":true}]`)
+
+	byOrg := map[int]interface{}{
+		1: []string{"retry storm"},
+		9: []string{"created after the org list was read"},
+	}
+	if err := NewPublisher(db, rdb).Publish(ctx, "findings", byOrg, []string{}, time.Hour); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	tests := []struct {
+		orgID    int
+		expected string
+	}{
+		{1, `["retry storm"]`},
+		{2, `[]`},
+		{3, `[]`},
+		{9, `["created after the org list was read"]`},
+	}
+	for _, tt := range tests {
+		key := Key("findings", tt.orgID)
+		got, err := mr.Get(key)
+		if err != nil {
+			t.Errorf("%s: %v", key, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%s = %s, want %s", key, got, tt.expected)
+		}
+		if ttl := mr.TTL(key); ttl != time.Hour {
+			t.Errorf("%s TTL = %v, want %v", key, ttl, time.Hour)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}